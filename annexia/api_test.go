@@ -0,0 +1,548 @@
+package annexia
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvalError(t *testing.T) {
+	f, _ := testForth(t)
+	err := f.Eval("1 2\n3 FOO 4")
+
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want an *Error", err)
+	}
+	if !errors.Is(err, ErrUndefinedWord) {
+		t.Errorf("got %v, want %v", e.Err, ErrUndefinedWord)
+	}
+	if e.Word != "FOO" || e.Line != 2 || e.Col != 3 {
+		t.Errorf("got %s at %d:%d, want FOO at 2:3", e.Word, e.Line, e.Col)
+	}
+	if len(e.Stack) != 3 {
+		t.Errorf("got stack %v, want the 3 cells at the error", e.Stack)
+	}
+	if len(f.DStack) != 0 || f.State != 0 {
+		t.Errorf("stack %v and state %d were not reset", f.DStack, f.State)
+	}
+	if err := f.Eval("5 6 + ."); err != nil {
+		t.Errorf("Eval after an error: %v", err)
+	}
+}
+
+func TestInvalidBase(t *testing.T) {
+	for _, src := range []string{"0 BASE ! 5 .", "0 BASE ! 5", "37 BASE ! 5 .", "1 BASE ! SEE TRUE", "0 BASE ! 1 .S", "0 BASE ! 5 2 .R", "37 BASE ! 5 2 U.R"} {
+		f, _ := testForth(t)
+		var e *Error
+		if err := f.Eval(src); !errors.As(err, &e) || !errors.Is(err, ErrInvalidNumber) {
+			t.Errorf("%s: got %v, want %v", src, err, ErrInvalidNumber)
+		}
+		if err := f.Eval("DECIMAL 5 ."); err != nil {
+			t.Errorf("%s: DECIMAL: %v", src, err)
+		}
+	}
+}
+
+func TestEvalBacktrace(t *testing.T) {
+	f, _ := testForth(t)
+	err := f.Eval(": A DROP ; : B A A ; 1 B")
+
+	var e *Error
+	if !errors.As(err, &e) || !errors.Is(err, ErrStackUnderflow) {
+		t.Fatalf("got %v, want stack underflow", err)
+	}
+	if strings.Join(e.Backtrace, " ") != "A B" {
+		t.Errorf("got backtrace %v, want [A B]", e.Backtrace)
+	}
+}
+
+func TestEvalReader(t *testing.T) {
+	f, out := testForth(t)
+	src := ": SUM\n  0 SWAP 0 DO I + LOOP ;\n5 SUM .\n"
+	if err := f.EvalReader(strings.NewReader(src)); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "10 " {
+		t.Errorf("got %q, want %q", out.String(), "10 ")
+	}
+}
+
+func TestEvalContextCancelled(t *testing.T) {
+	f, _ := testForth(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := f.EvalContext(ctx, ": L BEGIN AGAIN ; L")
+	if !errors.Is(err, ErrInterrupt) || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v wrapping %v", err, ErrInterrupt, context.Canceled)
+	}
+}
+
+func TestPushPop(t *testing.T) {
+	f, _ := testForth(t, WithStackLimits(4, 4))
+	if err := f.Push(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Eval("+"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := f.Pop(); v != 5 || err != nil {
+		t.Errorf("got %d, %v, want 5", v, err)
+	}
+	if _, err := f.Pop(); err != ErrStackUnderflow {
+		t.Errorf("Pop of an empty stack: got %v, want %v", err, ErrStackUnderflow)
+	}
+	if err := f.Push(1, 2, 3, 4, 5); err != ErrStackOverflow {
+		t.Errorf("Push past the limit: got %v, want %v", err, ErrStackOverflow)
+	}
+	if len(f.DStack) != 0 {
+		t.Errorf("a failed Push left %v", f.DStack)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	f, _ := testForth(t)
+	if err := f.Eval(": SQUARE DUP * ; : GONE ; LATEST @ HIDDEN"); err != nil {
+		t.Fatal(err)
+	}
+	if _, w := f.Lookup("square"); w == nil || w.Name != "SQUARE" {
+		t.Errorf("Lookup(square) = %v", w)
+	}
+	if _, w := f.Lookup("GONE"); w != nil {
+		t.Errorf("Lookup found the hidden word %s", w.Name)
+	}
+	if _, w := f.Lookup("NOPE"); w != nil {
+		t.Errorf("Lookup found %s", w.Name)
+	}
+}
+
+func TestDataSpace(t *testing.T) {
+	f, _ := testForth(t)
+	if err := f.Eval(`VARIABLE X 42 X ! CREATE S 2 C, CHAR h C, CHAR i C,`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Eval("X S"); err != nil {
+		t.Fatal(err)
+	}
+	str, _ := f.Pop()
+	addr, _ := f.Pop()
+	if v, err := f.Fetch(addr); v != 42 || err != nil {
+		t.Errorf("Fetch(X) = %d, %v, want 42", v, err)
+	}
+	if err := f.Store(addr, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Eval("X @"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.Pop(); v != 7 {
+		t.Errorf("X @ = %d after Store, want 7", v)
+	}
+
+	if s, err := f.Str(str + 1, 2); s != "hi" || err != nil {
+		t.Errorf("Str = %q, %v, want hi", s, err)
+	}
+
+	for _, addr := range []int{-1, DefaultDataSize} {
+		if _, err := f.Fetch(addr); err != ErrInvalidAddress {
+			t.Errorf("Fetch(%d): got %v, want %v", addr, err, ErrInvalidAddress)
+		}
+		if err := f.Store(addr, 1); err != ErrInvalidAddress {
+			t.Errorf("Store(%d): got %v, want %v", addr, err, ErrInvalidAddress)
+		}
+	}
+	if _, err := f.Bytes(DefaultDataSize - 1, 2); err != ErrInvalidAddress {
+		t.Errorf("Bytes past the end: got %v, want %v", err, ErrInvalidAddress)
+	}
+}
+
+func TestVariableBytes(t *testing.T) {
+	f, out := testForth(t)
+	if err := f.Eval("BASE C@ . 16 BASE C! BASE @ DECIMAL . BASE 1 DUMP"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "10 16 ") || !strings.Contains(out.String(), " 0a ") {
+		t.Errorf("got %q", out.String())
+	}
+
+	long := strings.Repeat("X", WordSize + 1)
+	for _, src := range []string{"WORD " + long, ": " + long + " ;"} {
+		if err := f.Eval(src); !errors.Is(err, ErrNameTooLong) {
+			t.Errorf("%s: got %v, want %v", src, err, ErrNameTooLong)
+		}
+	}
+	if err := f.Eval(": " + long[1:] + " ; " + long[1:]); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Run("CellSize", func(t *testing.T) {
+		f, out := testForth(t, WithCellSize(2))
+		if err := f.Eval("32767 1+ . 1 CELLS ."); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "-32768 2 " {
+			t.Errorf("got %q", out.String())
+		}
+	})
+
+	t.Run("DataSize", func(t *testing.T) {
+		f, _ := testForth(t, WithDataSize(AddrData + 64))
+		if err := f.Eval("32 ALLOT"); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Eval("64 ALLOT"); !errors.Is(err, ErrDictionaryOverflow) {
+			t.Errorf("got %v, want %v", err, ErrDictionaryOverflow)
+		}
+	})
+
+	t.Run("StepLimit", func(t *testing.T) {
+		f, _ := testForth(t, WithStepLimit(1000))
+		if err := f.Eval(": L BEGIN AGAIN ; L"); !errors.Is(err, ErrStepLimit) {
+			t.Errorf("got %v, want %v", err, ErrStepLimit)
+		}
+		if err := f.Eval("1 2 + DROP"); err != nil {
+			t.Errorf("the limit is per Eval: %v", err)
+		}
+	})
+
+	t.Run("OutputLimit", func(t *testing.T) {
+		f, out := testForth(t, WithOutputLimit(3))
+		if err := f.Eval("12345 ."); !errors.Is(err, ErrOutputLimit) {
+			t.Errorf("got %v, want %v", err, ErrOutputLimit)
+		}
+		if out.String() != "123" {
+			t.Errorf("got %q, want the first 3 bytes", out.String())
+		}
+	})
+
+	t.Run("DictLimit", func(t *testing.T) {
+		g, _ := testForth(t)
+		n := g.Pages.Offset + len(g.Pages.Dict)
+
+		f, _ := testForth(t, WithDictLimit(n + 1))
+		if err := f.Eval(": A ;"); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Eval(": B ;"); !errors.Is(err, ErrDictionaryOverflow) {
+			t.Errorf("got %v, want %v", err, ErrDictionaryOverflow)
+		}
+	})
+
+	t.Run("Hook", func(t *testing.T) {
+		h := &countHook{}
+		f, _ := testForth(t, WithHook(h))
+		if err := f.Eval(": A 1 DROP ; A A"); err != nil {
+			t.Fatal(err)
+		}
+		if h.enter != 2 || h.exit != 2 {
+			t.Errorf("got %d enters and %d exits, want 2 of each", h.enter, h.exit)
+		}
+	})
+}
+
+type countHook struct {
+	NopHook
+	enter, exit int
+}
+
+func (h *countHook) Enter(f *AnnexiaForth, word *ForthWord) {
+	if word.Name == "A" {
+		h.enter++
+	}
+}
+
+func (h *countHook) Exit(f *AnnexiaForth, word *ForthWord) {
+	if word.Name == "A" {
+		h.exit++
+	}
+}
+
+func TestTracer(t *testing.T) {
+	f, _ := testForth(t)
+	if err := f.Eval(`VARIABLE X 5 CONSTANT K 6 VALUE V : CONST CREATE , DOES> @ ; 7 CONST SEVEN
+		: SHOW X DROP K V SEVEN ;`); err != nil {
+		t.Fatal(err)
+	}
+	var tr bytes.Buffer
+	f.AddHook(NewTracer(&tr))
+	if err := f.Eval("SHOW"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, x := f.Lookup("X")
+	_, seven := f.Lookup("SEVEN")
+	body := fmt.Sprint(f.body(seven))
+	want := fmt.Sprintf(`: SHOW []
+  X []
+  DROP [%d]
+  K []
+  V [5]
+  : SEVEN [5 6 %s]
+    @ [5 6 %s]
+    EXIT [5 6 7]
+  ; SEVEN [5 6 7]
+  EXIT [5 6 7]
+; SHOW [5 6 7]
+`, f.body(x), body, body)
+	if tr.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", tr.String(), want)
+	}
+}
+
+func TestDefineHost(t *testing.T) {
+	f, out := testForth(t)
+	if err := f.Define("hypot2", func(a, b int) int { return a*a + b*b }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Define("upper", func(s string) (int, error) {
+		if s == "" {
+			return 0, errors.New("empty")
+		}
+		return len(strings.ToUpper(s)), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Eval(`3 4 HYPOT2 . : T S" abc" UPPER ; T .`); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "25 3 " {
+		t.Errorf("got %q", out.String())
+	}
+	if err := f.Eval(`S" " UPPER`); !errors.Is(err, ErrHost) {
+		t.Errorf("got %v, want %v", err, ErrHost)
+	}
+	if err := f.Define("BAD", func(s ...int) {}); err == nil {
+		t.Error("Define accepted a variadic func")
+	}
+	if err := f.Define("BAD", 42); err == nil {
+		t.Error("Define accepted a non-func")
+	}
+	var nilFunc func()
+	for _, fn := range []interface{}{nil, nilFunc} {
+		if err := f.Define("BAD", fn); err == nil {
+			t.Errorf("Define accepted %#v", fn)
+		}
+	}
+	if err := f.Define("", func() {}); err != ErrZeroLengthName {
+		t.Errorf("got %v, want %v", err, ErrZeroLengthName)
+	}
+	if _, w := f.Lookup("BAD"); w != nil {
+		t.Error("a failed Define added BAD")
+	}
+}
+
+func TestDefineCurrentPage(t *testing.T) {
+	f, out := testForth(t)
+	pages, n := f.Pages, f.Pages.Offset + len(f.Pages.Dict)
+	if err := f.Define("ONE", func() int { return 1 }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Define("TWO", func() int { return 2 }); err != nil {
+		t.Fatal(err)
+	}
+	if f.Pages != pages {
+		t.Error("Define added a page")
+	}
+	if err := f.Eval("ONE TWO + . FORGET TWO ONE ."); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "3 1 " {
+		t.Errorf("got %q", out.String())
+	}
+	if _, w := f.Lookup("TWO"); w != nil {
+		t.Error("FORGET left TWO")
+	}
+
+	g, _ := testForth(t, WithDictLimit(n + 1))
+	if err := g.Define("ONE", func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Define("TWO", func() {}); err != ErrDictionaryOverflow {
+		t.Errorf("got %v, want %v", err, ErrDictionaryOverflow)
+	}
+}
+
+func TestImageRoundTrip(t *testing.T) {
+	twice := func(x int) int { return 2 * x }
+	f, _ := testForth(t)
+	f.Define("TWICE", twice)
+	if err := f.Eval(`VARIABLE X 41 X ! : SHOW X @ 1+ . ; : CONST CREATE , DOES> @ ; 7 CONST SEVEN
+		5 VALUE V 6 TO V VOCABULARY VOC ALSO VOC DEFINITIONS : INV 3 ; PREVIOUS DEFINITIONS`); err != nil {
+		t.Fatal(err)
+	}
+	var img bytes.Buffer
+	if err := f.SaveImage(&img); err != nil {
+		t.Fatal(err)
+	}
+	raw := img.Bytes()
+
+	g, out := testForth(t)
+	if err := g.LoadImage(bytes.NewReader(raw)); !errors.Is(err, ErrBadImage) {
+		t.Errorf("loading without TWICE: got %v, want %v", err, ErrBadImage)
+	}
+	g.Define("TWICE", twice)
+	if err := g.LoadImage(bytes.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Eval("SHOW SEVEN . V . 10 TWICE . ALSO VOC INV ."); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "42 7 6 20 3 " {
+		t.Errorf("got %q", out.String())
+	}
+
+	bad := append([]byte(nil), raw...)
+	bad[len(bad)-1] ^= 1
+	images := [][]byte{raw[:len(raw)/2], bad}
+	for _, broken := range []func(*AnnexiaForth){
+		func(h *AnnexiaForth) { h.Latest = 1 << 20 },
+		func(h *AnnexiaForth) { h.Here = len(h.Pages.Memory) + 1 },
+		func(h *AnnexiaForth) { h.Base = 0 },
+		func(h *AnnexiaForth) { h.Current = h.Wordlists },
+		func(h *AnnexiaForth) { h.Order = []int{0, h.Wordlists} },
+	} {
+		h, _ := testForth(t)
+		broken(h)
+		var b bytes.Buffer
+		h.SaveImage(&b)
+		images = append(images, b.Bytes())
+	}
+	for i, b := range images {
+		if err := g.LoadImage(bytes.NewReader(b)); !errors.Is(err, ErrBadImage) {
+			t.Errorf("image %d: got %v, want %v", i, err, ErrBadImage)
+		}
+	}
+	out.Reset()
+	if err := g.Eval("SHOW INV ."); err != nil || out.String() != "42 3 " {
+		t.Errorf("after bad images: got %q, %v", out.String(), err)
+	}
+}
+
+func TestTransactionForget(t *testing.T) {
+	f, out := testForth(t)
+	if err := f.Eval("VARIABLE V 42 V ! : KEEP ;"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := f.Transaction(func() error { return f.Eval("99 V ! FORGET KEEP BOGUS") })
+	if !errors.Is(err, ErrInvalidForget) {
+		t.Errorf("got %v, want %v", err, ErrInvalidForget)
+	}
+	err = f.Transaction(func() error { return f.Eval("MARKER M : T ; M 99 V ! BOGUS") })
+	if !errors.Is(err, ErrUndefinedWord) {
+		t.Errorf("got %v, want %v", err, ErrUndefinedWord)
+	}
+
+	if err := f.Eval("V @ . ' KEEP DROP"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "42 " {
+		t.Errorf("got %q, want V untouched", out.String())
+	}
+	if err := f.Eval("FORGET KEEP"); err != nil {
+		t.Errorf("FORGET after the transaction: %v", err)
+	}
+}
+
+func TestConsoleReturnStack(t *testing.T) {
+	f, out := testForth(t)
+	var con strings.Builder
+	f.Debug = NewDebugger(Console(strings.NewReader(">r 7\n>r 8\nrdrop\nr>\n.s\nc\n"), &con))
+	f.Debug.Break("DROP")
+
+	if err := f.Eval(": A 5 DROP ; A ."); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "5 " {
+		t.Errorf("got %q, want DROP to take the 7 moved from the return stack", out.String())
+	}
+	if !strings.Contains(con.String(), "[5 7]") {
+		t.Errorf("console: %q", con.String())
+	}
+
+	con.Reset()
+	f.Debug = NewDebugger(Console(strings.NewReader("r>\n.s\nc\n"), &con))
+	f.Debug.Break("DROP")
+	if err := f.Eval(": B 5 DROP 6 ; : C B . ; C"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(con.String(), ErrInvalidAddress.Error() + "\ndebug> [5]") || !strings.HasSuffix(out.String(), "6 ") {
+		t.Errorf("r> of a return address: console %q, output %q", con.String(), out.String())
+	}
+}
+
+func TestProfileFoldedNames(t *testing.T) {
+	f, _ := testForth(t)
+	f.Define("NAP", func() { time.Sleep(2 * time.Millisecond) })
+	p := NewProfiler()
+	f.AddHook(p)
+	if err := f.Eval(": A; DUP DROP NAP ; : B 1 A; DROP ; B"); err != nil {
+		t.Fatal(err)
+	}
+
+	var folded, report strings.Builder
+	if err := p.WriteFolded(&folded); err != nil {
+		t.Fatal(err)
+	}
+	weights := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(folded.String()), "\n") {
+		i := strings.LastIndexByte(line, ' ')
+		weights[line[:i]], _ = strconv.Atoi(line[i+1:])
+	}
+	if _, ok := weights["B;A%3B;DUP"]; !ok || weights["B;A%3B;NAP"] < 2000 || weights["B;A%3B"] >= 2000 {
+		t.Errorf("folded:\n%s", folded.String())
+	}
+	p.WriteReport(&report)
+	if !strings.Contains(report.String(), " TOTAL-STEPS ") || !strings.Contains(report.String(), " TOTAL-TIME ") {
+		t.Errorf("report:\n%s", report.String())
+	}
+}
+
+func TestSeeRoundTrip(t *testing.T) {
+	f, _ := testForth(t)
+	for _, def := range []string{
+		": T1 IF 1 ELSE 2 THEN ;",
+		": T2 IF IF 1 ELSE 2 THEN ELSE 3 THEN DUP 0< IF NEGATE THEN ;",
+		": T3 BEGIN 1- DUP 0= UNTIL ;",
+		": T4 BEGIN DUP 0= IF EXIT THEN 1- AGAIN ;",
+		": T5 IF BEGIN 1- DUP 0= UNTIL THEN BEGIN BEGIN 1- DUP 5 < UNTIL DUP 0= UNTIL ;",
+		": T6 BEGIN DUP WHILE 1- REPEAT ;",
+		": T7 BEGIN DUP WHILE 1- DUP WHILE 1- REPEAT THEN ;",
+		": T8 BEGIN DUP WHILE DUP 2 > IF 1- ELSE 2 - THEN REPEAT ;",
+		": T9 10 0 DO I 5 = IF LEAVE THEN LOOP 0 10 0 DO I + 2 +LOOP ;",
+		": T10 3 0 DO 3 0 DO I J + DROP LOOP LOOP ?DUP IF 0 ?DO I . LOOP THEN ;",
+		": T11 CREATE , DOES> @ ;",
+		": T12 CREATE 0 , DOES> DUP @ 1+ SWAP ! ;",
+	} {
+		if err := f.Eval(def); err != nil {
+			t.Fatalf("%s: %v", def, err)
+		}
+		name := strings.Fields(def)[1]
+		_, word := f.Lookup(name)
+		want := append([]int(nil), word.Words...)
+
+		src, err := f.See(name)
+		if err != nil {
+			t.Fatalf("%s: %v", def, err)
+		}
+		if name == "T7" && src != def {
+			t.Errorf("SEE T7 = %q", src)
+		}
+		if err := f.Eval(src); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if _, word = f.Lookup(name); fmt.Sprint(word.Words) != fmt.Sprint(want) {
+			t.Errorf("%s compiled %v, want %v", src, word.Words, want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package annexia
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// walkCode is FindCode as it was before pages kept a code table: it walks
+// the page chain down to the page that holds code.
+func walkCode(p *ForthPage, code int) (*ForthWord) {
+	if code < 0 || code >= p.Offset + len(p.Dict) {
+		return nil
+	}
+	for code < p.Offset {
+		p = p.Parent
+	}
+	return p.Dict[code - p.Offset]
+}
+
+// stackPages adds n pages of one word each on top of f.
+func stackPages(f *AnnexiaForth, n int) {
+	for i := 0; i < n; i++ {
+		f.Pages = AddPage(f.Pages, f.root().Handler)
+		f.create(fmt.Sprintf("PAGE%d", i))
+	}
+}
+
+func BenchmarkFindCode(b *testing.B) {
+	f, _ := testForth(b)
+	stackPages(f, 64)
+	_, word := f.Lookup("DUP")
+	code, _ := f.Lookup("DUP")
+	if word == nil {
+		b.Fatal("no DUP")
+	}
+
+	b.Run("walk", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if walkCode(f.Pages, code) != word {
+				b.Fatal("wrong word")
+			}
+		}
+	})
+	b.Run("table", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, w := f.Pages.FindCode(code); w != word {
+				b.Fatal("wrong word")
+			}
+		}
+	})
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	f, _ := testForth(b)
+	f.push(1)
+	_, dup := f.Lookup("DUP")
+	_, drop := f.Lookup("DROP")
+
+	b.Run("name", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			RootHandler(f, dup)
+			RootHandler(f, drop)
+		}
+	})
+	b.Run("bound", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			dup.Exec(f, dup)
+			drop.Exec(f, drop)
+		}
+	})
+}
+
+func BenchmarkInner(b *testing.B) {
+	for _, pages := range []int{0, 64} {
+		b.Run(fmt.Sprintf("pages=%d", pages), func(b *testing.B) {
+			f, _ := testForth(b)
+			stackPages(f, pages)
+			if err := f.Eval(": L 0 DO I DUP + DROP LOOP ;"); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := f.Eval("1000 L"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// scanWord is FindWord as it was before pages were indexed: a reverse scan
+// of each page's Dict.
+func scanWord(p *ForthPage, name string) (int, *ForthWord) {
+	name = strings.ToUpper(name)
+	for ; p != nil; p = p.Parent {
+		for i := len(p.Dict)-1; i >= 0; i-- {
+			if w := p.Dict[i]; !w.Hidden && w.Name == name {
+				return p.Offset + i, w
+			}
+		}
+	}
+	return 0, nil
+}
+
+// bigDictionary returns source for n definitions of W0 to W<n-1>. After
+// every tenth one an earlier name is defined again, shadowing it, and every
+// hundredth such redefinition is hidden again.
+func bigDictionary(n int) (string) {
+	var src strings.Builder
+	src.WriteString(": W0 1 ;\n")
+	for i := 1; i < n; i++ {
+		fmt.Fprintf(&src, ": W%d W%d 1+ ;\n", i, i-1)
+		if i % 10 == 0 {
+			fmt.Fprintf(&src, ": W%d W%d DROP 0 ;\n", i/10, i-1)
+		}
+		if i % 1000 == 0 {
+			src.WriteString("LATEST @ HIDDEN\n")
+		}
+	}
+	return src.String()
+}
+
+func BenchmarkFindWord(b *testing.B) {
+	const n = 10000
+	f, _ := testForth(b)
+	if err := f.Eval(bigDictionary(n / 2)); err != nil {
+		b.Fatal(err)
+	}
+	stackPages(f, 1)
+	if err := f.Eval(bigDictionary(n / 2)); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, name := range []string{
+		fmt.Sprint("W", n/2 - 1), // latest
+		"W5",                      // shadowed on both pages
+		"W100",                    // shadow hidden, found below it
+		"DUP",                     // bottom of the root page
+		"NOSUCHWORD",
+	} {
+		code, word := scanWord(f.Pages, name)
+		if c, w := f.Pages.FindWord(name); c != code || w != word {
+			b.Fatalf("%s: got %d, want %d", name, c, code)
+		}
+		b.Run(name + "/scan", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scanWord(f.Pages, name)
+			}
+		})
+		b.Run(name + "/index", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				f.Pages.FindWord(name)
+			}
+		})
+	}
+}
+
+func BenchmarkCompile10k(b *testing.B) {
+	src := bigDictionary(10000)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		f, _ := testForth(b)
+		b.StartTimer()
+		if err := f.Eval(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package annexia
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Step says how a paused Debugger resumes.
+type Step int
+
+const (
+	// Continue runs until the next breakpoint.
+	Continue Step = iota
+	// StepInto pauses before the next word, inside colon definitions too.
+	StepInto
+	// StepOver pauses before the next word at the same depth or above.
+	StepOver
+	// StepOut pauses once the current definition has returned.
+	StepOut
+)
+
+// Stop describes a pause: Word is about to execute from position POS of
+// In, the colon definition being threaded, or from the outer interpreter
+// if In is nil. Depth is the depth of the return stack.
+type Stop struct {
+	Code  int
+	Word  *ForthWord
+	In    *ForthWord
+	POS   int
+	Depth int
+}
+
+// Debugger pauses a VM before words with a breakpoint and while stepping.
+// At each pause it calls Pause, which may inspect and change the stacks
+// and data space of the VM and returns how to continue. Set the Debug
+// field of a VM to use it.
+type Debugger struct {
+	Pause  func(f *AnnexiaForth, at Stop) Step
+
+	breaks map[string]bool
+	step   Step
+	depth  int
+}
+
+// NewDebugger returns a debugger without breakpoints that calls pause.
+func NewDebugger(pause func(f *AnnexiaForth, at Stop) Step) (*Debugger) {
+	return &Debugger{Pause: pause, breaks: make(map[string]bool)}
+}
+
+// Break sets a breakpoint on the words called name.
+func (d *Debugger) Break(name string) {
+	d.breaks[strings.ToUpper(name)] = true
+}
+
+// Unbreak removes the breakpoint on name.
+func (d *Debugger) Unbreak(name string) {
+	delete(d.breaks, strings.ToUpper(name))
+}
+
+// Breakpoints returns the names with a breakpoint in order.
+func (d *Debugger) Breakpoints() (names []string) {
+	for name := range d.breaks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Resume makes the debugger carry on as if a pause had returned s, so
+// StepInto stops at the first word of the next Eval.
+func (d *Debugger) Resume(s Step) {
+	d.step, d.depth = s, 0
+}
+
+// check pauses before word if it has a breakpoint or the last pause asked
+// to stop there.
+func (d *Debugger) check(f *AnnexiaForth, code int, word *ForthWord) {
+	depth := len(f.RStack)
+	stop := d.breaks[word.Name]
+	switch d.step {
+	case StepInto:
+		stop = true
+	case StepOver:
+		stop = stop || depth <= d.depth
+	case StepOut:
+		stop = stop || depth < d.depth
+	}
+	if !stop || d.Pause == nil {
+		return
+	}
+
+	at := Stop{Code: code, Word: word, In: f.RSP.Word, POS: f.RSP.POS, Depth: depth}
+	d.step, d.depth = d.Pause(f, at), depth
+}
+
+// Console returns a Pause func for interactive use. It shows each stop on
+// out and reads commands from in until one resumes:
+//
+//	s, step          step into
+//	n, next          step over
+//	f, finish        step out
+//	c, continue      continue to the next breakpoint
+//	b, break NAME    set a breakpoint
+//	d, delete NAME   remove a breakpoint
+//	.s               show the data stack
+//	rs, bt           show the return stack
+//	push N           push N onto the data stack
+//	drop             drop the top of the data stack
+//	>r N             push N onto the return stack
+//	r>               move a number on the return stack to the data stack
+//	rdrop            drop the top of the return stack
+//	@ ADDR           show the cell at ADDR
+//	! N ADDR         store N in the cell at ADDR
+//
+// Numbers are read in the current BASE. The end of in continues.
+func Console(in io.Reader, out io.Writer) (func(f *AnnexiaForth, at Stop) Step) {
+	r := bufio.NewReader(in)
+	return func(f *AnnexiaForth, at Stop) Step {
+		where := "interpreter"
+		if at.In != nil {
+			where = at.In.Name + " +" + strconv.Itoa(at.POS)
+		}
+		fmt.Fprintf(out, "break %s in %s %v\n", at.Word.Name, where, f.DStack)
+
+		for {
+			fmt.Fprint(out, "debug> ")
+			line, err := r.ReadString('\n')
+			if err != nil && line == "" {
+				return Continue
+			}
+			args := strings.Fields(line)
+			if len(args) == 0 {
+				continue
+			}
+			if s, ok := f.console(out, args); ok {
+				return s
+			}
+		}
+	}
+}
+
+// console runs one debugger command and reports whether it resumes.
+func (f *AnnexiaForth) console(out io.Writer, args []string) (s Step, resume bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(Exception); !ok {
+				panic(r)
+			}
+			fmt.Fprintln(out, r)
+			resume = false
+		}
+	}()
+
+	num := func(i int) int {
+		if i >= len(args) {
+			Throw(ErrStackUnderflow)
+		}
+		v, ok := f.Number(args[i])
+		if !ok {
+			Throw(ErrInvalidNumber)
+		}
+		return v
+	}
+	name := func() string {
+		if len(args) < 2 {
+			Throw(ErrZeroLengthName)
+		}
+		return args[1]
+	}
+
+	switch args[0] {
+	case "s", "step":
+		return StepInto, true
+	case "n", "next":
+		return StepOver, true
+	case "f", "finish":
+		return StepOut, true
+	case "c", "continue":
+		return Continue, true
+	case "b", "break":
+		f.Debug.Break(name())
+	case "d", "delete":
+		f.Debug.Unbreak(name())
+	case ".s":
+		fmt.Fprintln(out, f.DStack)
+	case "rs", "bt":
+		for i := len(f.RStack)-1; i >= 0; i-- {
+			if wp := f.RStack[i]; wp.Word != nil {
+				fmt.Fprintf(out, "  %s +%d\n", wp.Word.Name, wp.POS)
+			} else {
+				fmt.Fprintf(out, "  %d\n", wp.Code)
+			}
+		}
+	case "push":
+		f.push(num(1))
+	case "drop":
+		f.pop()
+	case ">r":
+		f.rpush(num(1))
+	case "r>":
+		f.rneed(1)
+		if f.RStack[len(f.RStack)-1].Word != nil {
+			// A return address has a position that a number would lose.
+			Throw(ErrInvalidAddress)
+		}
+		f.push(f.RStack[len(f.RStack)-1].Code)
+		f.RStack = f.RStack[:len(f.RStack)-1]
+	case "rdrop":
+		f.rneed(1)
+		f.RStack = f.RStack[:len(f.RStack)-1]
+	case "@":
+		fmt.Fprintln(out, f.fetch(num(1)))
+	case "!":
+		f.store(num(2), num(1))
+	default:
+		fmt.Fprintln(out, "unknown command", args[0])
+	}
+	return
+}
@@ -0,0 +1,107 @@
+package annexia
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Define adds a native word called name that calls fn. The parameters and
+// results of fn may be any integer kind or bool, and a string parameter
+// takes a c-addr u pair. Arguments are popped so the last parameter comes
+// from the top of the stack, and results are pushed in order. If the last
+// result is an error and it is not nil it is raised with THROW: as is if
+// it wraps an Exception and as ErrHost otherwise.
+func (f *AnnexiaForth) Define(name string, fn interface{}) (error) {
+	v := reflect.ValueOf(fn)
+	if !v.IsValid() || v.Kind() != reflect.Func || v.IsNil() || v.Type().IsVariadic() {
+		return fmt.Errorf("define %s: %T is not a fixed arity func", name, fn)
+	}
+	t := v.Type()
+
+	for i := 0; i < t.NumIn(); i++ {
+		if in := t.In(i); !isCell(in) && in.Kind() != reflect.String {
+			return fmt.Errorf("define %s: unsupported parameter %s", name, in)
+		}
+	}
+	for i := 0; i < t.NumOut(); i++ {
+		out := t.Out(i)
+		if out == errorType && i == t.NumOut()-1 {
+			continue
+		}
+		if !isCell(out) {
+			return fmt.Errorf("define %s: unsupported result %s", name, out)
+		}
+	}
+
+	return try(func() {
+		word := f.create(name)
+		word.Native = true
+		word.Exec = func(ctx *AnnexiaForth, w *ForthWord) { ctx.call(v) }
+	})
+}
+
+// call pops the arguments of fn, calls it and pushes its results.
+func (f *AnnexiaForth) call(fn reflect.Value) {
+	t := fn.Type()
+
+	args := make([]reflect.Value, t.NumIn())
+	for i := len(args)-1; i >= 0; i-- {
+		in := t.In(i)
+		switch {
+		case in.Kind() == reflect.String:
+			n := f.pop()
+			args[i] = reflect.ValueOf(f.str(f.pop(), n)).Convert(in)
+		case in.Kind() == reflect.Bool:
+			args[i] = reflect.ValueOf(f.pop() != 0).Convert(in)
+		default:
+			args[i] = reflect.New(in).Elem()
+			if isUnsigned(in) {
+				args[i].SetUint(uint64(f.pop()))
+			} else {
+				args[i].SetInt(int64(f.pop()))
+			}
+		}
+	}
+
+	results := fn.Call(args)
+	if n := len(results); n > 0 && results[n-1].Type() == errorType {
+		if err, _ := results[n-1].Interface().(error); err != nil {
+			var e Exception
+			if errors.As(err, &e) {
+				Throw(e)
+			}
+			Throw(ErrHost)
+		}
+		results = results[:n-1]
+	}
+
+	for _, r := range results {
+		switch {
+		case r.Kind() == reflect.Bool:
+			f.push(flag(r.Bool()))
+		case isUnsigned(r.Type()):
+			f.push(int(r.Uint()))
+		default:
+			f.push(int(r.Int()))
+		}
+	}
+}
+
+func isCell(t reflect.Type) (bool) {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return isUnsigned(t)
+}
+
+func isUnsigned(t reflect.Type) (bool) {
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
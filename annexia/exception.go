@@ -0,0 +1,203 @@
+package annexia
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Exception is a THROW code. Primitives raise one by panicking with it and
+// CATCH or the outer interpreter recovers it.
+type Exception int
+
+// Standard THROW codes.
+const (
+	ErrAbort           Exception = -1
+	ErrAbortQuote      Exception = -2
+	ErrStackOverflow   Exception = -3
+	ErrStackUnderflow  Exception = -4
+	ErrReturnOverflow  Exception = -5
+	ErrReturnUnderflow Exception = -6
+	ErrDictionaryOverflow Exception = -8
+	ErrInvalidAddress  Exception = -9
+	ErrDivisionByZero  Exception = -10
+	ErrUnsupported     Exception = -21
+	ErrUndefinedWord   Exception = -13
+	ErrCompileOnly     Exception = -14
+	ErrInvalidForget   Exception = -15
+	ErrZeroLengthName  Exception = -16
+	ErrNameTooLong     Exception = -19
+	ErrStringOverflow  Exception = -18
+	ErrInvalidNumber   Exception = -24
+	ErrControlMismatch Exception = -22
+	ErrInterrupt       Exception = -28
+	ErrNotCreated      Exception = -31
+	ErrInvalidName     Exception = -32
+	ErrFileIO          Exception = -37
+	ErrOrderOverflow   Exception = -49
+	ErrOrderUnderflow  Exception = -50
+)
+
+// ErrHost is thrown when a Go function registered with Define fails with
+// an error that is not an Exception.
+const ErrHost Exception = -256
+
+// ErrStepLimit and ErrOutputLimit are thrown when a script exceeds
+// MaxSteps or MaxOutput.
+const (
+	ErrStepLimit   Exception = -257
+	ErrOutputLimit Exception = -258
+)
+
+var exceptionText = map[Exception]string{
+	ErrAbort:           "aborted",
+	ErrAbortQuote:      "aborted",
+	ErrStackOverflow:   "stack overflow",
+	ErrStackUnderflow:  "stack underflow",
+	ErrReturnOverflow:  "return stack overflow",
+	ErrReturnUnderflow: "return stack underflow",
+	ErrDictionaryOverflow: "dictionary overflow",
+	ErrInvalidAddress:  "invalid memory address",
+	ErrDivisionByZero:  "division by zero",
+	ErrUnsupported:     "unsupported operation",
+	ErrUndefinedWord:   "undefined word",
+	ErrCompileOnly:     "interpreting a compile-only word",
+	ErrInvalidForget:   "invalid FORGET",
+	ErrZeroLengthName:  "attempt to use zero-length string as a name",
+	ErrNameTooLong:     "definition name too long",
+	ErrStringOverflow:  "parsed string overflow",
+	ErrInvalidNumber:   "invalid numeric argument",
+	ErrControlMismatch: "control structure mismatch",
+	ErrInterrupt:       "user interrupt",
+	ErrNotCreated:      ">BODY used on non-CREATEd definition",
+	ErrInvalidName:     "invalid name argument",
+	ErrFileIO:          "file I/O exception",
+	ErrOrderOverflow:   "search-order overflow",
+	ErrOrderUnderflow:  "search-order underflow",
+	ErrHost:            "host function failed",
+	ErrStepLimit:       "step limit exceeded",
+	ErrOutputLimit:     "output limit exceeded",
+}
+
+func (e Exception) Error() string {
+	if s, ok := exceptionText[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("exception %d", int(e))
+}
+
+// Error is an exception that escaped the outer interpreter, along with
+// where it happened. Err holds the Exception so errors.Is can match it
+// against the Err constants.
+type Error struct {
+	Err       error
+	Word      string
+	Line, Col int
+	Stack     []int
+	Backtrace []string
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("%d:%d: %s: %v %v", e.Line, e.Col, e.Word, e.Err, e.Stack)
+	if len(e.Backtrace) > 0 {
+		s += " in " + strings.Join(e.Backtrace, " < ")
+	}
+	return s
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrap turns a recovered Exception into an *Error describing the current
+// state of f. Anything else is returned unchanged.
+func (f *AnnexiaForth) wrap(r interface{}) interface{} {
+	exc, ok := r.(Exception)
+	if !ok {
+		return r
+	}
+
+	e := &Error{
+		Err:   exc,
+		Word:  f.name,
+		Line:  f.Line,
+		Col:   f.col + 1,
+		Stack: append([]int(nil), f.DStack...),
+	}
+	if f.W.Word != nil {
+		e.Word = f.W.Word.Name
+	}
+	if f.RSP.Word != nil {
+		e.Backtrace = append(e.Backtrace, f.RSP.Word.Name)
+	}
+	for i := len(f.RStack) - 1; i >= 0; i-- {
+		if f.RStack[i].Word != nil {
+			e.Backtrace = append(e.Backtrace, f.RStack[i].Word.Name)
+		}
+	}
+	for _, h := range f.hooks {
+		h.Error(f, e)
+	}
+
+	return e
+}
+
+// Throw raises e unless it is zero.
+func Throw(e Exception) {
+	if e != 0 {
+		panic(e)
+	}
+}
+
+// try calls fn and returns the Exception it throws, if any.
+func try(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(Exception)
+			if !ok {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+
+	fn()
+	return nil
+}
+
+// Catch executes code and returns the exception that unwound it, or zero.
+// The data and return stacks are restored to their depth on entry. The
+// ErrInterrupt of a cancelled EvalContext is not caught.
+func (f *AnnexiaForth) Catch(code int) (e Exception) {
+	depth, rdepth, rsp := len(f.DStack), len(f.RStack), f.RSP
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err, ok := r.(error)
+		if !ok || !errors.As(err, &e) || e == ErrInterrupt && f.interrupted() {
+			panic(r)
+		}
+
+		for len(f.DStack) < depth {
+			f.DStack = append(f.DStack, 0)
+		}
+		f.DStack, f.RStack, f.RSP = f.DStack[:depth], f.RStack[:rdepth], rsp
+	}()
+
+	_, word := f.Pages.FindCode(code)
+	if word == nil {
+		Throw(ErrUndefinedWord)
+	}
+	f.execute(code, word)
+
+	return 0
+}
+
+// Abort empties both stacks and returns to interpreting.
+func (f *AnnexiaForth) Abort() {
+	f.DStack, f.RStack, f.RSP = f.DStack[:0], f.RStack[:0], WordPtr{}
+	f.State = 0
+}
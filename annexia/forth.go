@@ -0,0 +1,587 @@
+// Package annexia is a Forth in the style of jonesforth. Words live in a
+// chain of ForthPages and colon definitions are indirect threaded through
+// their Words.
+package annexia
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"strconv"
+)
+
+func to_int(token string, base int) (int, bool) {
+	if i, err := strconv.ParseInt(token, base, 64); err == nil {
+		return int(i), true
+	}
+	return 0, false
+}
+
+type ForthWord struct {
+	Name      string
+	Page      *ForthPage
+	Immediate bool
+	Hidden    bool
+	CompileOnly bool
+	Native    bool
+	List      int
+	Exec      ForthHandle
+	Words     []int
+}
+type ForthPage struct {
+	Parent    *ForthPage
+	Offset    int
+	Dict      []*ForthWord
+	Memory    []byte
+	Handler   ForthHandle
+	Ops       map[string]ForthHandle
+
+	index     map[string][]int
+	codes     []*ForthWord
+}
+
+// ForthHandle runs a native word. A word's Exec is called directly by the
+// inner interpreter, so it is bound once when the word is defined.
+type ForthHandle func(ctx *AnnexiaForth, word *ForthWord)
+type WordPtr struct {
+	Code  int
+	Word  *ForthWord
+	POS   int
+	Page  *ForthPage
+
+	// child is the word whose DOES> code the frame runs. Hooks are told
+	// about it rather than the defining word holding the code.
+	child *ForthWord
+}
+
+// Byte addresses of the built-in variables and the WORD buffer. Each
+// variable gets an 8 byte slot so it is aligned at any cell size. User
+// data starts at AddrData.
+const (
+	AddrState = 8 * iota
+	AddrHere
+	AddrLatest
+	AddrS0
+	AddrBase
+	AddrToIn
+	AddrWord
+	AddrTIB  = AddrWord + WordSize
+	AddrStr  = AddrTIB + TIBSize
+	AddrData = AddrStr + 2 * StrSize
+)
+
+// TIBSize is the number of input characters visible through SOURCE,
+// WordSize the length of the WORD buffer and StrSize the length of each
+// of the two buffers S" uses while interpreting.
+const (
+	TIBSize  = 1024
+	WordSize = 32
+	StrSize  = 256
+)
+
+// DefaultDataSize is the size in bytes of the data space, including the
+// built-in variables and buffers below AddrData.
+const DefaultDataSize = 64 * 1024
+
+// Version is the value pushed by VERSION.
+const Version = 1
+
+// ForthWordlist is the wordlist of the core words. AnyWordlist makes
+// Search match a word in any wordlist.
+const (
+	ForthWordlist = 0
+	AnyWordlist   = -1
+)
+
+// SearchOrderSize is the most wordlists the search order can hold.
+const SearchOrderSize = 16
+
+// checkInterval is how many words NEXT executes between checks of the
+// context passed to EvalContext.
+const checkInterval = 256
+
+// Default maximum depths of the data and return stacks.
+const (
+	DefaultDStackSize = 1024
+	DefaultRStackSize = 1024
+)
+
+type AnnexiaForth struct {
+	State     int
+	Latest    int
+	Here 	  int
+	SZ 	      int
+	Base      int
+
+	W         WordPtr
+	RSP       WordPtr
+	RStack    []WordPtr
+	DStack    []int
+	Pages     *ForthPage
+	Order     []int
+	Current   int
+	Wordlists int
+
+	Input     *bufio.Reader
+	SourceID  int
+	TIB       string
+	ToIn      int
+	Line      int
+
+	name      string
+	col       int
+	sbuf      int
+	csp       int
+	floor     *ForthPage
+	internals map[string]int
+
+	Stdin      io.Reader
+	Stdout     io.Writer
+	CellSize   int
+	DataSize   int
+	DStackSize int
+	RStackSize int
+
+	// Limits on a script. Zero means no limit. Steps and output are
+	// counted from the start of each Eval.
+	MaxSteps   int
+	MaxOutput  int
+	MaxWords   int
+
+	Debug      *Debugger
+	hooks      []Hook
+
+	steps      int
+	output     int
+	ctx        context.Context
+}
+
+// NewForth returns a VM with the primitives and core words defined.
+func NewForth(opts ...Option) (f *AnnexiaForth) {
+	f = &AnnexiaForth{
+		Base:       10,
+		Here:       AddrData,
+		Order:      []int{ForthWordlist},
+		Wordlists:  1,
+		Stdin:      os.Stdin,
+		Stdout:     os.Stdout,
+		CellSize:   8,
+		DataSize:   DefaultDataSize,
+		DStackSize: DefaultDStackSize,
+		RStackSize: DefaultRStackSize,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	p := AddPage(nil, RootHandler)
+	p.Memory = make([]byte, max(f.DataSize, AddrData))
+	p.Ops = primitives
+
+	p.DefCode("DOCOL")
+	p.DefCode("DOVAR")
+	p.DefCode("DOCON")
+	p.DefCode("DOVAL")
+	p.DefCode("DODOES")
+	p.DefCode("DOMARKER")
+	p.DefCode("DOVOCAB")
+
+	// Easy FORTH Primitives
+	p.DefCode("DROP")
+	p.DefCode("SWAP")
+	p.DefCode("DUP")
+	p.DefCode("OVER")
+	p.DefCode("ROT")
+	p.DefCode("-ROT")
+	p.DefCode("2DROP")
+	p.DefCode("2DUP")
+	p.DefCode("2SWAP")
+	p.DefCode("?DUP")
+	p.DefCode("1+")
+	p.DefCode("1-")
+	p.DefCode("4+")
+	p.DefCode("4-")
+	p.DefCode("+")
+	p.DefCode("-")
+	p.DefCode("*")
+	p.DefCode("/MOD")
+
+	// Comparison Ops
+	p.DefCode("=")
+	p.DefCode("<>")
+	p.DefCode("<")
+	p.DefCode(">")
+	p.DefCode("<=")
+	p.DefCode(">=")
+	p.DefCode("0=")
+	p.DefCode("0<>")
+	p.DefCode("0<")
+	p.DefCode("0>")
+	p.DefCode("0<=")
+	p.DefCode("0>=")
+	p.DefCode("AND")
+	p.DefCode("OR")
+	p.DefCode("XOR")
+	p.DefCode("INVERT")
+	
+	
+	// Literals
+	p.DefCode("LIT")
+	p.DefCode("LITSTRING")
+	p.DefCode("TELL")
+
+	// Strings
+	p.DefCode(`S"`).SetImmediate()
+	p.DefCode(`S\"`).SetImmediate()
+	p.DefCode(`C"`).SetImmediate()
+	p.DefCode(`."`).SetImmediate()
+	p.DefCode(".(").SetImmediate()
+	p.DefCode("TYPE")
+	p.DefCode("COUNT")
+
+	// Memory
+	p.DefCode("!")
+	p.DefCode("@")
+	p.DefCode("+!")
+	p.DefCode("-!")
+	p.DefCode("C!")
+	p.DefCode("C@")
+	p.DefCode("2!")
+	p.DefCode("2@")
+
+	// Data Space
+	p.DefCode("ALLOT")
+	p.DefCode("ALIGN")
+	p.DefCode("ALIGNED")
+	p.DefCode("CELLS")
+	p.DefCode("CELL+")
+	p.DefCode("CHARS")
+	p.DefCode("CHAR+")
+	p.DefCode("C,")
+
+	// Built-in Variables
+	p.DefCode("STATE")
+	p.DefCode("HERE")
+	p.DefCode("LATEST")
+	p.DefCode("S0")
+	p.DefCode("BASE")
+
+	// Built-in Constants
+	p.DefCode("VERSION")
+	p.DefCode("R0")
+	p.DefCode("_DOCOL")
+	p.DefCode("__F_IMMED")
+	p.DefCode("__F_HIDDEN")
+	p.DefCode("__F_LENMASK")
+
+	// Return Stack
+	p.DefCode(">R")
+	p.DefCode("R>")
+	p.DefCode("RSP@")
+	p.DefCode("RSP!")
+	p.DefCode("RDROP")
+	
+	// Data Stack
+	p.DefCode("DSP@")
+	p.DefCode("DSP!")
+	
+	// Input and Output
+	p.DefCode("KEY")
+	p.DefCode("EMIT")
+	p.DefCode("SPACES")
+	p.DefCode("WORD")
+	p.DefCode("NUMBER")
+	p.DefCode("PARSE")
+	p.DefCode("SOURCE")
+	p.DefCode(">IN")
+	p.DefCode("REFILL")
+	p.DefCode("SOURCE-ID")
+	
+	// Dictionary Ops
+	p.DefCode("FIND")
+	p.DefCode(">CFA")
+	
+	// Compiling
+	p.DefCode("(CREATE)")
+	p.DefCode("!CSP")
+	p.DefCode("?CSP")
+	p.DefCode(",")
+	p.DefCode("COMPILE,")
+	p.DefCode(".")
+	
+	// Immediate
+	p.DefCode("[").SetImmediate()
+	p.DefCode("]")
+	p.DefCode("IMMEDIATE").SetImmediate()
+	p.DefCode("HIDDEN")
+	p.DefCode("'")
+	
+	// Branching
+	p.DefCode("BRANCH")
+	p.DefCode("0BRANCH")
+	p.DefCode("(DO)")
+	p.DefCode("(?DO)")
+	p.DefCode("(LOOP)")
+	p.DefCode("(+LOOP)")
+	p.DefCode("I")
+	p.DefCode("J")
+	p.DefCode("LEAVE")
+	p.DefCode("UNLOOP")
+
+	// Control Structures
+	p.DefCode("IF").SetImmediate().SetCompileOnly()
+	p.DefCode("ELSE").SetImmediate().SetCompileOnly()
+	p.DefCode("THEN").SetImmediate().SetCompileOnly()
+	p.DefCode("BEGIN").SetImmediate().SetCompileOnly()
+	p.DefCode("UNTIL").SetImmediate().SetCompileOnly()
+	p.DefCode("AGAIN").SetImmediate().SetCompileOnly()
+	p.DefCode("WHILE").SetImmediate().SetCompileOnly()
+	p.DefCode("REPEAT").SetImmediate().SetCompileOnly()
+	p.DefCode("DO").SetImmediate().SetCompileOnly()
+	p.DefCode("?DO").SetImmediate().SetCompileOnly()
+	p.DefCode("LOOP").SetImmediate().SetCompileOnly()
+	p.DefCode("+LOOP").SetImmediate().SetCompileOnly()
+	p.DefCode("RECURSE").SetImmediate().SetCompileOnly()
+	
+	// Interpreting
+	p.DefCode("INTERPRET")
+	p.DefCode("EXIT")
+	p.DefCode("CHAR")
+	p.DefCode("EXECUTE")
+	p.DefCode("LITERAL").SetImmediate().SetCompileOnly()
+	p.DefCode("(").SetImmediate()
+	p.DefCode("\\").SetImmediate()
+
+	// Defining Words
+	p.DefCode("CREATE")
+	p.DefCode("(DOES>)")
+	p.DefCode("DOES>").SetImmediate().SetCompileOnly()
+	p.DefCode(">BODY")
+	p.DefCode("VARIABLE")
+	p.DefCode("2VARIABLE")
+	p.DefCode("BUFFER:")
+	p.DefCode("CONSTANT")
+	p.DefCode("2CONSTANT")
+	p.DefCode("VALUE")
+	p.DefCode("TO").SetImmediate()
+	p.DefCode("+TO").SetImmediate()
+	p.DefCode("MARKER")
+	p.DefCode("FORGET")
+
+	// Search Order
+	p.DefCode("FORTH-WORDLIST")
+	p.DefCode("WORDLIST")
+	p.DefCode("VOCABULARY")
+	p.DefCode("FORTH")
+	p.DefCode("ALSO")
+	p.DefCode("ONLY")
+	p.DefCode("PREVIOUS")
+	p.DefCode("DEFINITIONS")
+	p.DefCode("GET-ORDER")
+	p.DefCode("SET-ORDER")
+	p.DefCode("GET-CURRENT")
+	p.DefCode("SET-CURRENT")
+	p.DefCode("SEARCH-WORDLIST")
+
+	// Debugging
+	p.DefCode("WORDS")
+	p.DefCode(".S")
+	p.DefCode("DUMP")
+	p.DefCode(".R")
+	p.DefCode("U.R")
+	p.DefCode("ENVIRONMENT?")
+	p.DefCode("SEE")
+	p.DefCode("BREAK")
+	p.DefCode("UNBREAK")
+	p.DefCode("PROFILE-ON")
+	p.DefCode("PROFILE-OFF")
+	p.DefCode("PROFILE-REPORT")
+
+	// Images
+	p.DefCode("SAVE-IMAGE")
+	p.DefCode("LOAD-IMAGE")
+
+	// Exceptions
+	p.DefCode("CATCH")
+	p.DefCode("THROW")
+	p.DefCode("ABORT")
+
+	f.bind(p)
+	p = AddPage(p, RootHandler)
+	f.Pages = p
+
+	// Misc. Words
+	p.DefWord("DOUBLE",    "DUP +", f.Base)
+	p.DefWord("QUADRUPLE", "DOUBLE DOUBLE", f.Base)
+	p.DefWord(">DFA",      ">CFA 1+", f.Base)
+	p.DefWord(":",         "WORD (CREATE) LIT DOCOL COMPILE, LATEST @ HIDDEN !CSP ]", f.Base)
+	p.DefWord(";",         "?CSP LIT EXIT COMPILE, LATEST @ HIDDEN [", f.Base).SetImmediate().SetCompileOnly()
+	p.DefWord("HIDE",      "WORD FIND HIDDEN", f.Base)
+	p.DefWord("QUIT",      "R0 RSP! INTERPRET BRANCH -2", f.Base)
+	f.Latest = p.Offset + len(p.Dict) - 1
+
+	return
+}
+func AddPage(p *ForthPage, h ForthHandle) (np *ForthPage){
+	
+	np = &ForthPage{Parent: p, Handler: h}
+	if p != nil {
+		np.Offset = p.Offset + len(p.Dict)
+		np.Memory = append([]byte(nil), p.Memory...)
+	}
+
+	return
+}
+func (p *ForthPage) DefCode(name string) (*ForthWord){
+	code := &ForthWord{Name:name, Native: true, Page: p, Exec: p.Handler}
+	if op, ok := p.Ops[name]; ok {
+		code.Exec = op
+	}
+	p.Add(code)
+	return code
+}
+func (p *ForthPage) DefWord(name, words string, base int) (*ForthWord){
+	var w []int
+	pos, _ := p.FindWord("DOCOL")
+	w = append(w, pos)
+	for _, word := range strings.Fields(words) {
+		pos, found := p.FindWord(word)
+		if found == nil {
+			pos, _ = to_int(word, base)
+		}
+		w = append(w, pos)
+	}
+	pos, _ = p.FindWord("EXIT")
+	w = append(w, pos)
+
+	code := &ForthWord{Name:name, Native: false, Words: w, Page: p}
+	p.Add(code)
+	return code
+}
+
+// Add appends w to the page and indexes it by name. Words must be added
+// through Add for FindWord to see them.
+func (p *ForthPage) Add(w *ForthWord) {
+	if p.index == nil {
+		p.index = make(map[string][]int)
+	}
+	p.index[w.Name] = append(p.index[w.Name], len(p.Dict))
+	p.Dict = append(p.Dict, w)
+}
+
+// Truncate drops the words of p from n on.
+func (p *ForthPage) Truncate(n int) {
+	for _, w := range p.Dict[n:] {
+		defs := p.index[w.Name]
+		p.index[w.Name] = defs[:len(defs)-1]
+	}
+	p.Dict = p.Dict[:n]
+}
+func (w *ForthWord) SetImmediate() (*ForthWord){
+	w.Immediate = !w.Immediate
+	return w
+}
+func (w *ForthWord) SetHidden() (*ForthWord){
+	w.Hidden = !w.Hidden
+	return w
+}
+func (w *ForthWord) SetCompileOnly() (*ForthWord){
+	w.CompileOnly = !w.CompileOnly
+	return w
+}
+// FindWord returns the most recent visible word called name on p or its
+// parents, whatever wordlist it is in. Each page indexes every definition
+// of a name, so hiding a word uncovers the one it shadowed without
+// rebuilding anything.
+func (p *ForthPage) FindWord(name string) (int, *ForthWord) {
+	return p.Search(name, AnyWordlist)
+}
+
+// Search is FindWord for the words of wordlist wid.
+func (p *ForthPage) Search(name string, wid int) (int, *ForthWord) {
+	name = strings.ToUpper(name)
+
+	for {
+		defs := p.index[name]
+		for i := len(defs)-1; i >= 0; i-- {
+			if w := p.Dict[defs[i]]; !w.Hidden && (wid == AnyWordlist || w.List == wid) {
+				return p.Offset + defs[i], w
+			}
+		}
+		if p.Parent == nil {
+			break
+		}
+		p = p.Parent
+	}
+
+	return 0, nil
+}
+
+// FindCode returns the word with the given code. Words of lower pages are
+// looked up in a table built on first use, so the inner interpreter does
+// not walk the page chain on every step.
+func (p *ForthPage) FindCode(code int) (int, *ForthWord) {
+	if code < 0 || code >= p.Offset + len(p.Dict) {
+		return 0, nil
+	}
+	if code >= p.Offset {
+		return code, p.Dict[code - p.Offset]
+	}
+
+	if len(p.codes) != p.Offset {
+		p.codes = make([]*ForthWord, p.Offset)
+		for q := p.Parent; q != nil; q = q.Parent {
+			copy(p.codes[q.Offset:], q.Dict)
+		}
+	}
+
+	return code, p.codes[code]
+}
+func (p *ForthPage) Print(out io.Writer) {
+	for {
+		for w := len(p.Dict)-1; w >= 0; w-- {
+			if p.Dict[w].Hidden {
+				continue
+			}
+			fmt.Fprintln(out, "WORD ", w + p.Offset, p.Dict[w].Name)
+		}
+		if p.Parent == nil {
+			break
+		}
+		p = p.Parent
+	}
+}
+
+
+
+
+var BOOTSTRAP string = `
+  : /    /MOD SWAP DROP ;
+  : MOD  /MOD DROP ;
+  : '\n' 10   ;
+  : BL   32   ;
+  : CR   '\N' EMIT ;
+  : SPACE BL EMIT ;
+  : NEGATE 0 SWAP - ;
+  : TRUE 1 ;
+  : FALSE 0 ;
+  : NIP  SWAP DROP ;
+  : TUCK SWAP OVER ;
+  : DOUBLE    DUP + ;
+  : QUADRUPLE DOUBLE DOUBLE ;
+  : DECIMAL 10 BASE ! ;
+  : HEX     16 BASE ! ;
+  : ? ( addr -- ) @ . ;
+  : ':' [ CHAR : ] LITERAL ;
+  : ';' [ CHAR ; ] LITERAL ;
+  : '(' [ CHAR ( ] LITERAL ;
+  : ')' [ CHAR ) ] LITERAL ;
+  : '"' [ CHAR " ] LITERAL ;
+  : 'A' [ CHAR A ] LITERAL ;
+  : '0' [ CHAR 0 ] LITERAL ;
+  : '-' [ CHAR - ] LITERAL ;
+  : '.' [ CHAR . ] LITERAL ;
+`
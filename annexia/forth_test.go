@@ -0,0 +1,281 @@
+package annexia
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testForth returns a bootstrapped VM writing to the returned buffer.
+func testForth(t testing.TB, opts ...Option) (*AnnexiaForth, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	f := NewForth(append([]Option{WithStdout(&out)}, opts...)...)
+	if err := f.Eval(BOOTSTRAP); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	return f, &out
+}
+
+func TestSemicolonChecksControlFlow(t *testing.T) {
+	for _, src := range []string{
+		": X 1 IF ;",
+		": X BEGIN ;",
+		": X 10 0 DO ;",
+		": X IF ELSE ;",
+	} {
+		f, _ := testForth(t)
+		err := f.Eval(src)
+		if !errors.Is(err, ErrControlMismatch) {
+			t.Errorf("%s: got %v, want %v", src, err, ErrControlMismatch)
+		}
+		if len(f.DStack) != 0 {
+			t.Errorf("%s: left %v on the stack", src, f.DStack)
+		}
+		if _, w := f.Lookup("X"); w != nil {
+			t.Errorf("%s: X is visible", src)
+		}
+	}
+
+	f, out := testForth(t)
+	if err := f.Eval(": X [ 5 ] LITERAL IF 1 ELSE 2 THEN . ; X"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "1 " {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestMalformedCodeThrows(t *testing.T) {
+	for _, src := range []string{
+		"LIT",
+		"BRANCH",
+		"0 0BRANCH",
+		"DOVAR",
+		"1 2 (DO)",
+		"(LOOP)",
+		"LITSTRING",
+		"' DOCOL EXECUTE",
+		"' DOCON EXECUTE",
+		"' DODOES EXECUTE",
+		"' LITSTRING EXECUTE",
+		": X [ ' 0BRANCH COMPILE, 0 COMPILE, ] ; 0 X",
+		": Y [ ' BRANCH COMPILE, 100 COMPILE, ] ; Y",
+	} {
+		f, _ := testForth(t)
+		var e *Error
+		if err := f.Eval(src); !errors.As(err, &e) {
+			t.Errorf("%s: got %v, want an *Error", src, err)
+		}
+	}
+
+	f, _ := testForth(t)
+	if err := f.Eval("' LIT CATCH"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.Pop(); Exception(v) != ErrInvalidAddress {
+		t.Errorf("CATCH returned %d, want %d", v, ErrInvalidAddress)
+	}
+}
+
+func TestRunContinuesAfterError(t *testing.T) {
+	var out bytes.Buffer
+	f := NewForth(WithStdout(&out), WithStdin(strings.NewReader("1 .\nFOO 2 .\nLIT\n3 .\n")))
+	if err := f.Run(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "1 ") || !strings.Contains(lines[0], "undefined word") ||
+		!strings.Contains(lines[1], "invalid memory address") || lines[2] != "3 " {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestRootWords(t *testing.T) {
+	f, out := testForth(t)
+	if err := f.Eval("VERSION . 1 2 3 DSP@ . 1 DSP! . 2 SPACES"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "1 3 1   " {
+		t.Errorf("got %q", out.String())
+	}
+
+	if err := f.Eval(": X ; _DOCOL ' X >CFA @ = . ' __F_IMMED CATCH ."); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.String(), "-1 -21 ") {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestToolWordsOutput(t *testing.T) {
+	f, out := testForth(t)
+	if err := f.Eval(": QUADRUPLE 4 * ; WORDS"); err != nil {
+		t.Fatal(err)
+	}
+	if s := out.String(); !strings.HasPrefix(s, "QUADRUPLE ") || !strings.HasSuffix(s, "\n") {
+		t.Errorf("WORDS: got %q", s)
+	}
+
+	for _, c := range []struct{ src, want string }{
+		{"1 -2 3 .S", "<3> 1 -2 3 "},
+		{"HEX 1F .S DECIMAL", "<1> 1f "},
+		{"5 4 .R -12 2 .R", "   5-12"},
+		{"-1 3 U.R", "18446744073709551615"},
+		{`S" MAX-CHAR" ENVIRONMENT? . .`, "-1 255 "},
+		{`S" NO-SUCH-QUERY" ENVIRONMENT? .`, "0 "},
+	} {
+		f, out := testForth(t)
+		if err := f.Eval(c.src); err != nil {
+			t.Errorf("%s: %v", c.src, err)
+		} else if out.String() != c.want {
+			t.Errorf("%s: got %q, want %q", c.src, out.String(), c.want)
+		}
+	}
+
+	f, out = testForth(t)
+	if err := f.Eval("CREATE B CHAR A C, CHAR b C, 10 C, B ."); err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := strconv.Atoi(strings.TrimSpace(out.String()))
+	out.Reset()
+	if err := f.Eval("B 3 DUMP"); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("%08x  41 62 0a%s  |Ab.|\n", addr, strings.Repeat("   ", 13) + " ")
+	if out.String() != want {
+		t.Errorf("DUMP: got %q, want %q", out.String(), want)
+	}
+}
+
+func TestCompilerIgnoresShadows(t *testing.T) {
+	f, out := testForth(t)
+	for _, src := range []string{
+		"VOCABULARY Q ALSO Q DEFINITIONS : LIT 7 ; PREVIOUS DEFINITIONS : Y 3 ; Y .",
+		"VARIABLE A 7 VALUE V",
+		": LIT 7 ; : BRANCH ; : 0BRANCH ; : EXIT ; : (DO) ; : (LOOP) ; : TELL ;",
+		": DOVAR ; : DOCON ; : DOVAL ; : DODOES ; : LITSTRING ; : ! ; : +! ;",
+		`: Z 0 IF 1 ELSE 2 THEN 3 0 DO I + LOOP ." z" ; Z .`,
+		"VARIABLE B B A > . 6 CONSTANT C C . : SETV TO V ; 8 SETV V .",
+		": D CREATE , DOES> @ ; 9 D E E .",
+	} {
+		if err := f.Eval(src); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+	}
+	if out.String() != "3 z5 -1 6 8 9 " {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestColonWordsNest(t *testing.T) {
+	f, out := testForth(t)
+	src := ": A 1 ; : B A A + ; : C B B * ; C . 3 QUADRUPLE . : E 1 EXIT 2 ; E ."
+	for i := 0; i < 50; i++ {
+		src += " : N" + strconv.Itoa(i+1) + " N" + strconv.Itoa(i) + " 1+ ;"
+	}
+	if err := f.Eval(": N0 0 ; " + src + " N50 ."); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "4 12 1 50 " {
+		t.Errorf("got %q", out.String())
+	}
+	if len(f.DStack) != 0 || len(f.RStack) != 0 {
+		t.Errorf("left %v and %v on the stacks", f.DStack, f.RStack)
+	}
+
+	_, c := f.Lookup("C")
+	if c == nil || c.Words[0] != f.code("DOCOL") || c.Words[len(c.Words)-1] != f.code("EXIT") {
+		t.Errorf("C compiled to %v", c)
+	}
+}
+
+func TestColonCompiler(t *testing.T) {
+	f, out := testForth(t)
+	for _, src := range []string{
+		"WORD hello . DROP STATE @ .",
+		"HERE 5 , 6 , DUP @ . CELL+ @ .",
+		"WORD SEVEN (CREATE) ' DOCOL COMPILE, ' LIT COMPILE, 7 COMPILE, ' EXIT COMPILE, SEVEN .",
+		": U STATE @ . ; IMMEDIATE : V U [ U ] ;",
+		": W [ 2 3 + ] LITERAL ; W .",
+	} {
+		if err := f.Eval(src); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+	}
+	if out.String() != "5 0 5 6 7 1 0 5 " {
+		t.Errorf("got %q", out.String())
+	}
+	if f.State != 0 || len(f.DStack) != 0 {
+		t.Errorf("STATE %d, stack %v", f.State, f.DStack)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	f, out := testForth(t)
+	for _, src := range []string{
+		`S\" a\tb\x41\"\\" TYPE`,
+		`: G S\" x\ny\q" TYPE ; G`,
+		`C" hello" COUNT TYPE C" hi" C@ .`,
+		`: H C" abc" COUNT TYPE ; H`,
+		`.( paren) : P .( now) ." later" ; P`,
+		`S" one" S" two" TYPE TYPE`,
+	} {
+		if err := f.Eval(src); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+	}
+	if want := "a\tbA\"\\x\ny\"hello2 abcparennowlatertwoone"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	long := strings.Repeat("x", 256)
+	for _, src := range []string{`S" ` + long + `x"`, `C" ` + long + `"`} {
+		if err := f.Eval(src); !errors.Is(err, ErrStringOverflow) {
+			t.Errorf("got %v, want %v", err, ErrStringOverflow)
+		}
+	}
+}
+
+func TestSearchOrder(t *testing.T) {
+	f, out := testForth(t)
+	for _, src := range []string{
+		"WORDLIST CONSTANT W GET-CURRENT . W SET-CURRENT : SECRET 5 ; FORTH-WORDLIST SET-CURRENT",
+		`S" SECRET" W SEARCH-WORDLIST . EXECUTE . S" SECRET" FORTH-WORDLIST SEARCH-WORDLIST .`,
+		`S" IF" FORTH-WORDLIST SEARCH-WORDLIST . DROP`,
+		"GET-ORDER W SWAP 1+ SET-ORDER SECRET . GET-ORDER . . . ONLY GET-ORDER . .",
+		"VOCABULARY V ALSO V DEFINITIONS : HELLO 1 ; PREVIOUS DEFINITIONS ALSO V HELLO . PREVIOUS",
+		"ALSO V DEFINITIONS : DUP 2 ; DUP . FORTH 3 DUP . . ONLY FORTH DEFINITIONS",
+	} {
+		if err := f.Eval(src); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+	}
+	if want := "0 -1 5 0 1 5 2 1 0 1 0 1 2 3 3 "; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	for _, tt := range []struct {
+		src  string
+		want Exception
+	}{
+		{"SECRET", ErrUndefinedWord},
+		{"HELLO", ErrUndefinedWord},
+		{": P PREVIOUS PREVIOUS ; ONLY P", ErrOrderUnderflow},
+		{"17 SET-ORDER", ErrOrderOverflow},
+		{": A ALSO ; A A A A A A A A A A A A A A A A", ErrOrderOverflow},
+	} {
+		f, _ := testForth(t)
+		if err := f.Eval("WORDLIST SET-CURRENT : SECRET ; FORTH-WORDLIST SET-CURRENT " +
+			"VOCABULARY V ALSO V DEFINITIONS : HELLO ; ONLY DEFINITIONS"); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Eval(tt.src); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.src, err, tt.want)
+		}
+	}
+}
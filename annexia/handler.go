@@ -0,0 +1,922 @@
+package annexia
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// primitives are the native words of the root page, by name.
+var primitives = map[string]ForthHandle{
+	"DOCOL": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.cells(1)
+		ctx.enter(WordPtr{Code: ctx.W.Code, Word: ctx.W.Word, POS: 1, Page: ctx.W.Word.Page})
+	},
+	"DOVAR": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.cells(2)[1])
+	},
+	"DOCON": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.cells(2)[1:]...)
+	},
+	"DOVAL": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.fetch(ctx.cells(2)[1]))
+	},
+	"DODOES": func(ctx *AnnexiaForth, w *ForthWord) {
+		words := ctx.cells(4)
+		does := ctx.word(words[2])
+		ctx.push(words[1])
+		ctx.enter(WordPtr{Code: words[2], Word: does, POS: words[3], Page: does.Page, child: ctx.W.Word})
+	},
+	"DOMARKER": func(ctx *AnnexiaForth, w *ForthWord) {
+		words := ctx.cells(3)
+		ctx.forget(ctx.W.Code, words[1])
+		ctx.Current, ctx.Order = words[2], append([]int(nil), words[3:]...)
+	},
+	"DOVOCAB": func(ctx *AnnexiaForth, w *ForthWord) {
+		wid := ctx.cells(2)[1]
+		if len(ctx.Order) == 0 {
+			ctx.Order = append(ctx.Order, wid)
+		}
+		*ctx.top() = wid
+	},
+
+	"EXIT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.leave()
+	},
+
+	"DROP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(1)
+		ctx.DStack = ctx.DStack[:len(ctx.DStack)-1]	
+	},
+	"SWAP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(2)
+		ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-1] = 
+			ctx.DStack[len(ctx.DStack)-1], ctx.DStack[len(ctx.DStack)-2]
+	},
+	"DUP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(1)
+		ctx.push(ctx.DStack[len(ctx.DStack)-1])
+	},
+	"OVER": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(2)
+		ctx.push(ctx.DStack[len(ctx.DStack)-2])
+	},
+	"ROT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(3)
+		a, b, c := ctx.DStack[len(ctx.DStack)-1], ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-3]
+		ctx.DStack[len(ctx.DStack)-1], ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-3] = c, a, b
+	},
+	"-ROT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(3)
+		a, b, c := ctx.DStack[len(ctx.DStack)-1], ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-3]
+		ctx.DStack[len(ctx.DStack)-1], ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-3] = b, c, a
+	},
+	"2DROP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(2)
+		ctx.DStack = ctx.DStack[:len(ctx.DStack)-2]
+	},
+	"2DUP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(2)
+		ctx.push(ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-1])
+	},
+	"2SWAP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(4)
+		a, b, c, d := ctx.DStack[len(ctx.DStack)-1], ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-3], ctx.DStack[len(ctx.DStack)-4]
+		ctx.DStack[len(ctx.DStack)-1], ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-3], ctx.DStack[len(ctx.DStack)-4] = c, d, a, b 
+	},
+	"?DUP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(1)
+		if ctx.DStack[len(ctx.DStack)-1] != 0 {
+			ctx.push(ctx.DStack[len(ctx.DStack)-1])
+		}
+	},
+
+	"1+": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() + 1)
+	},
+	"1-": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() - 1)
+	},
+
+	"4+": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() + 4)
+	},
+	"4-": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() - 4)
+	},
+
+	"+": func(ctx *AnnexiaForth, w *ForthWord) {
+		i := ctx.pop()
+		ctx.push(ctx.pop() + i)
+	},
+	"-": func(ctx *AnnexiaForth, w *ForthWord) {
+		i := ctx.pop()
+		ctx.push(ctx.pop() - i)
+	},
+	"*": func(ctx *AnnexiaForth, w *ForthWord) {
+		i := ctx.pop()
+		ctx.push(ctx.pop() * i)
+	},
+	"/MOD": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(2)
+		if ctx.DStack[len(ctx.DStack)-1] == 0 {
+			Throw(ErrDivisionByZero)
+		}
+		d := ctx.pop()
+		n := ctx.pop()
+		ctx.push(n % d, n / d)
+	},
+
+	"=": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compare(func(a, b int) bool { return a == b })
+	},
+	"<>": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compare(func(a, b int) bool { return a != b })
+	},
+	"<": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compare(func(a, b int) bool { return a < b })
+	},
+	">": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compare(func(a, b int) bool { return a > b })
+	},
+	"<=": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compare(func(a, b int) bool { return a <= b })
+	},
+	">=": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compare(func(a, b int) bool { return a >= b })
+	},
+	"0=": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(flag(ctx.pop() == 0))
+	},
+	"0<>": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(flag(ctx.pop() != 0))
+	},
+	"0<": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(flag(ctx.pop() < 0))
+	},
+	"0>": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(flag(ctx.pop() > 0))
+	},
+	"0<=": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(flag(ctx.pop() <= 0))
+	},
+	"0>=": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(flag(ctx.pop() >= 0))
+	},
+	"AND": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() & ctx.pop())
+	},
+	"OR": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() | ctx.pop())
+	},
+	"XOR": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() ^ ctx.pop())
+	},
+	"INVERT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(^ctx.pop())
+	},
+
+	"BRANCH": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.branch()
+	},
+	"0BRANCH": func(ctx *AnnexiaForth, w *ForthWord) {
+		if ctx.pop() == 0 {
+			ctx.branch()
+		} else {
+			ctx.RSP.next()
+		}
+	},
+
+	"(DO)": opDo,
+	"(?DO)": opDo,
+	"(LOOP)": opLoop,
+	"(+LOOP)": opLoop,
+	"I": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.rneed(1)
+		ctx.push(ctx.RStack[len(ctx.RStack)-1].Code)
+	},
+	"J": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.rneed(4)
+		ctx.push(ctx.RStack[len(ctx.RStack)-4].Code)
+	},
+	"LEAVE": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.rneed(3)
+		ctx.RSP.POS = ctx.RStack[len(ctx.RStack)-3].Code
+		ctx.RStack = ctx.RStack[:len(ctx.RStack)-3]
+	},
+	"UNLOOP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.rneed(3)
+		ctx.RStack = ctx.RStack[:len(ctx.RStack)-3]
+	},
+
+	"IF": opIf,
+	"WHILE": opIf,
+	"ELSE": func(ctx *AnnexiaForth, w *ForthWord) {
+		orig := ctx.cpop()
+		ctx.compile(ctx.code("BRANCH"))
+		ctx.push(ctx.mark())
+		ctx.compile(0)
+		ctx.resolve(orig)
+	},
+	"THEN": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.resolve(ctx.cpop())
+	},
+	"BEGIN": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.mark())
+	},
+	"UNTIL": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compile(ctx.code("0BRANCH"))
+		ctx.compile(ctx.cpop() - ctx.mark())
+	},
+	"AGAIN": opAgain,
+	"REPEAT": opAgain,
+	"DO": opCompileDo,
+	"?DO": opCompileDo,
+	"LOOP": opCompileLoop,
+	"+LOOP": opCompileLoop,
+	"RECURSE": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compile(ctx.Latest)
+	},
+
+	">R": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.rpush(ctx.pop())
+	},
+	"R>": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.rneed(1)
+		ctx.push(ctx.RStack[len(ctx.RStack)-1].Code)
+		ctx.RStack = ctx.RStack[:len(ctx.RStack)-1]
+	},
+	"RDROP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.rneed(1)
+		ctx.RStack = ctx.RStack[:len(ctx.RStack)-1]
+	},
+
+	"LIT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.RSP.next())
+	},
+	"LITERAL": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compile(ctx.code("LIT"), ctx.pop())
+	},
+
+	"!": func(ctx *AnnexiaForth, w *ForthWord) {
+		addr := ctx.pop()
+		ctx.store(addr, ctx.pop())
+	},
+	"@": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.fetch(ctx.pop()))
+	},
+	"+!": func(ctx *AnnexiaForth, w *ForthWord) {
+		addr := ctx.pop()
+		ctx.store(addr, ctx.fetch(addr) + ctx.pop())
+	},
+	"-!": func(ctx *AnnexiaForth, w *ForthWord) {
+		addr := ctx.pop()
+		ctx.store(addr, ctx.fetch(addr) - ctx.pop())
+	},
+	"C!": func(ctx *AnnexiaForth, w *ForthWord) {
+		addr := ctx.pop()
+		ctx.bytes(addr, 1)[0] = byte(ctx.pop())
+		ctx.reload(addr)
+	},
+	"C@": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(int(ctx.bytes(ctx.pop(), 1)[0]))
+	},
+	"2!": func(ctx *AnnexiaForth, w *ForthWord) {
+		addr := ctx.pop()
+		ctx.store(addr, ctx.pop())
+		ctx.store(addr + ctx.CellSize, ctx.pop())
+	},
+	"2@": func(ctx *AnnexiaForth, w *ForthWord) {
+		addr := ctx.pop()
+		ctx.push(ctx.fetch(addr + ctx.CellSize), ctx.fetch(addr))
+	},
+
+	"ALLOT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.allot(ctx.pop())
+	},
+	"ALIGN": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.allot(ctx.aligned(ctx.Here) - ctx.Here)
+	},
+	"ALIGNED": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.aligned(ctx.pop()))
+	},
+	"CELLS": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() * ctx.CellSize)
+	},
+	"CELL+": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() + ctx.CellSize)
+	},
+	"CHARS": func(ctx *AnnexiaForth, w *ForthWord) {
+		// Characters are one byte.
+	},
+	"CHAR+": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.pop() + 1)
+	},
+	"C,": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.bytes(ctx.allot(1), 1)[0] = byte(ctx.pop())
+	},
+
+	"STATE": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(AddrState)
+	},
+	"HERE": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.Here)
+	},
+	"LATEST": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(AddrLatest)
+	},
+	"S0": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(AddrS0)
+	},
+	"BASE": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(AddrBase)
+	},
+
+	"WORD": func(ctx *AnnexiaForth, w *ForthWord) {
+		name := ctx.Word()
+		if len(name) > WordSize {
+			Throw(ErrNameTooLong)
+		}
+		copy(ctx.bytes(AddrWord, len(name)), name)
+		ctx.push(AddrWord, len(name))
+	},
+	"NUMBER": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		if v, ok := ctx.Number(ctx.str(ctx.pop(), n)); ok {
+			ctx.push(v, 0)
+		} else {
+			ctx.push(0, n)
+		}
+	},
+	"CHAR": func(ctx *AnnexiaForth, w *ForthWord) {
+		name := ctx.Word()
+		if name == "" {
+			Throw(ErrZeroLengthName)
+		}
+		ctx.push(int(name[0]))
+	},
+	"(": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.Parse(')')
+	},
+	"\\": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.ToIn = len(ctx.TIB)
+	},
+	"PARSE": func(ctx *AnnexiaForth, w *ForthWord) {
+		start := ctx.skip(0)
+		name := ctx.Parse(byte(ctx.pop()))
+		ctx.push(AddrTIB + start, len(name))
+	},
+	"KEY": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.Key())
+	},
+
+	"SOURCE": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(AddrTIB, min(len(ctx.TIB), TIBSize))
+	},
+	">IN": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(AddrToIn)
+	},
+	"SOURCE-ID": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.SourceID)
+	},
+	"REFILL": func(ctx *AnnexiaForth, w *ForthWord) {
+		if ctx.SourceID != -1 && ctx.Refill() {
+			ctx.push(-1)
+		} else {
+			ctx.push(0)
+		}
+	},
+
+	"INTERPRET": func(ctx *AnnexiaForth, w *ForthWord) {
+		name := ctx.Word()
+		for name == "" {
+			if !ctx.Refill() {
+				ctx.RSP, ctx.RStack = WordPtr{}, ctx.RStack[:0]
+				return
+			}
+			name = ctx.Word()
+		}
+		ctx.interpret(name)
+	},
+
+	"VERSION": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(Version)
+	},
+	"R0": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(0)
+	},
+	"_DOCOL": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.code("DOCOL"))
+	},
+	"RSP@": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(len(ctx.RStack))
+	},
+	"RSP!": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		if n < 0 || n > len(ctx.RStack) {
+			Throw(ErrReturnUnderflow)
+		}
+		ctx.RStack = ctx.RStack[:n]
+	},
+	"DSP@": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(len(ctx.DStack))
+	},
+	"DSP!": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		if n < 0 || n > len(ctx.DStack) {
+			Throw(ErrStackUnderflow)
+		}
+		ctx.DStack = ctx.DStack[:n]
+	},
+
+	"FIND": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		if code, word := ctx.Lookup(ctx.str(ctx.pop(), n)); word != nil {
+			ctx.push(code)
+		} else {
+			ctx.push(0)
+		}
+	},
+	">CFA": func(ctx *AnnexiaForth, w *ForthWord) {
+		// Codes are already code field addresses.
+	},
+
+	"(CREATE)": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		ctx.create(ctx.str(ctx.pop(), n))
+	},
+	"!CSP": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.csp = len(ctx.DStack)
+	},
+	"?CSP": func(ctx *AnnexiaForth, w *ForthWord) {
+		// Anything IF, BEGIN or DO left that was never resolved shows up
+		// as a change in depth since the colon.
+		if len(ctx.DStack) != ctx.csp {
+			Throw(ErrControlMismatch)
+		}
+	},
+	",": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.store(ctx.allot(ctx.CellSize), ctx.pop())
+	},
+	"COMPILE,": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compile(ctx.pop())
+	},
+	"[": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.State = 0
+	},
+	"]": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.State = 1
+	},
+	"IMMEDIATE": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.word(ctx.Latest).SetImmediate()
+	},
+	"HIDDEN": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.word(ctx.pop()).SetHidden()
+	},
+	"'": func(ctx *AnnexiaForth, w *ForthWord) {
+		if ctx.RSP.Word != nil {
+			ctx.push(ctx.RSP.next())
+		} else {
+			name := ctx.Word()
+			if name == "" {
+				Throw(ErrZeroLengthName)
+			}
+			code, word := ctx.Lookup(name)
+			if word == nil {
+				Throw(ErrUndefinedWord)
+			}
+			ctx.push(code)
+		}
+	},
+	"EXECUTE": func(ctx *AnnexiaForth, w *ForthWord) {
+		code := ctx.pop()
+		ctx.exec(code, ctx.word(code))
+	},
+
+	"CREATE": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.allot(ctx.aligned(ctx.Here) - ctx.Here)
+		ctx.create(ctx.Word(), ctx.code("DOVAR"), ctx.Here)
+	},
+	"(DOES>)": func(ctx *AnnexiaForth, w *ForthWord) {
+		if ctx.RSP.Word == nil {
+			Throw(ErrInvalidAddress)
+		}
+		word := ctx.word(ctx.Latest)
+		ctx.body(word)
+		word.Words = []int{ctx.code("DODOES"), word.Words[1], ctx.RSP.Code, ctx.RSP.POS}
+
+		// The rest of the definition is the DOES> code, so leave it as
+		// EXIT would.
+		ctx.leave()
+	},
+	"DOES>": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.compile(ctx.code("(DOES>)"))
+	},
+	">BODY": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.body(ctx.word(ctx.pop())))
+	},
+	"MARKER": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.create(ctx.Word(), append([]int{ctx.code("DOMARKER"), ctx.Here, ctx.Current}, ctx.Order...)...)
+		ctx.Pages = AddPage(ctx.Pages, ctx.root().Handler)
+	},
+	"FORGET": func(ctx *AnnexiaForth, w *ForthWord) {
+		name := ctx.Word()
+		if name == "" {
+			Throw(ErrZeroLengthName)
+		}
+		code, word := ctx.Lookup(name)
+		if word == nil {
+			Throw(ErrUndefinedWord)
+		}
+		here := ctx.Here
+		if !word.Native && len(word.Words) > 1 {
+			switch word.Words[0] {
+			case ctx.code("DOVAR"), ctx.code("DOVAL"), ctx.code("DODOES"):
+				here = word.Words[1]
+			}
+		}
+		ctx.forget(code, here)
+	},
+	"VARIABLE": opVariable,
+	"2VARIABLE": opVariable,
+	"BUFFER:": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		name := ctx.Word()
+		ctx.allot(ctx.aligned(ctx.Here) - ctx.Here)
+		ctx.create(name, ctx.code("DOVAR"), ctx.allot(n))
+	},
+	"CONSTANT": func(ctx *AnnexiaForth, w *ForthWord) {
+		v := ctx.pop()
+		ctx.create(ctx.Word(), ctx.code("DOCON"), v)
+	},
+	"2CONSTANT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.need(2)
+		v := ctx.DStack[len(ctx.DStack)-2:]
+		ctx.create(ctx.Word(), ctx.code("DOCON"), v[0], v[1])
+		ctx.DStack = ctx.DStack[:len(ctx.DStack)-2]
+	},
+	"VALUE": func(ctx *AnnexiaForth, w *ForthWord) {
+		v := ctx.pop()
+		ctx.allot(ctx.aligned(ctx.Here) - ctx.Here)
+		addr := ctx.allot(ctx.CellSize)
+		ctx.store(addr, v)
+		ctx.create(ctx.Word(), ctx.code("DOVAL"), addr)
+	},
+	"TO": opTo,
+	"+TO": opTo,
+
+	"FORTH-WORDLIST": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ForthWordlist)
+	},
+	"WORDLIST": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.Wordlists)
+		ctx.Wordlists++
+	},
+	"VOCABULARY": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.create(ctx.Word(), ctx.code("DOVOCAB"), ctx.Wordlists)
+		ctx.Wordlists++
+	},
+	"FORTH": func(ctx *AnnexiaForth, w *ForthWord) {
+		*ctx.top() = ForthWordlist
+	},
+	"ALSO": func(ctx *AnnexiaForth, w *ForthWord) {
+		if len(ctx.Order) >= SearchOrderSize {
+			Throw(ErrOrderOverflow)
+		}
+		ctx.Order = append(ctx.Order, *ctx.top())
+	},
+	"ONLY": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.Order = []int{ForthWordlist}
+	},
+	"PREVIOUS": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.top()
+		ctx.Order = ctx.Order[:len(ctx.Order)-1]
+	},
+	"DEFINITIONS": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.Current = *ctx.top()
+	},
+	"GET-ORDER": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.Order...)
+		ctx.push(len(ctx.Order))
+	},
+	"SET-ORDER": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		switch {
+		case n < 0:
+			ctx.Order = []int{ForthWordlist}
+		case n > SearchOrderSize:
+			Throw(ErrOrderOverflow)
+		default:
+			ctx.need(n)
+			ctx.Order = append([]int(nil), ctx.DStack[len(ctx.DStack)-n:]...)
+			ctx.DStack = ctx.DStack[:len(ctx.DStack)-n]
+		}
+	},
+	"GET-CURRENT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(ctx.Current)
+	},
+	"SET-CURRENT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.Current = ctx.pop()
+	},
+	"SEARCH-WORDLIST": func(ctx *AnnexiaForth, w *ForthWord) {
+		wid, n := ctx.pop(), ctx.pop()
+		code, word := ctx.Pages.Search(ctx.str(ctx.pop(), n), wid)
+		switch {
+		case word == nil:
+			ctx.push(0)
+		case word.Immediate:
+			ctx.push(code, 1)
+		default:
+			ctx.push(code, -1)
+		}
+	},
+
+	"WORDS": func(ctx *AnnexiaForth, w *ForthWord) {
+		col := 0
+		for _, name := range ctx.Words() {
+			if col > 0 && col + len(name) >= 80 {
+				ctx.write("\n")
+				col = 0
+			}
+			ctx.write(name + " ")
+			col += len(name) + 1
+		}
+		ctx.write("\n")
+	},
+	".S": func(ctx *AnnexiaForth, w *ForthWord) {
+		base := ctx.radix()
+		s := "<" + strconv.Itoa(len(ctx.DStack)) + "> "
+		for _, v := range ctx.DStack {
+			s += strconv.FormatInt(int64(v), base) + " "
+		}
+		ctx.write(s)
+	},
+	"DUMP": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		ctx.write(ctx.dump(ctx.pop(), n))
+	},
+	".R": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		ctx.write(rjust(strconv.FormatInt(int64(ctx.pop()), ctx.radix()), n))
+	},
+	"U.R": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		ctx.write(rjust(strconv.FormatUint(ctx.unsigned(ctx.pop()), ctx.radix()), n))
+	},
+	"ENVIRONMENT?": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		v, ok := ctx.Environment(ctx.str(ctx.pop(), n))
+		if ok {
+			ctx.push(append(v, -1)...)
+		} else {
+			ctx.push(0)
+		}
+	},
+	"SEE": func(ctx *AnnexiaForth, w *ForthWord) {
+		code, word := ctx.Lookup(ctx.Word())
+		if word == nil {
+			Throw(ErrUndefinedWord)
+		}
+		ctx.write(ctx.decompile(code, word) + "\n")
+	},
+	"BREAK": func(ctx *AnnexiaForth, w *ForthWord) {
+		name := ctx.Word()
+		if name == "" {
+			Throw(ErrZeroLengthName)
+		}
+		if ctx.Debug == nil {
+			Throw(ErrUnsupported)
+		}
+		ctx.Debug.Break(name)
+	},
+	"UNBREAK": func(ctx *AnnexiaForth, w *ForthWord) {
+		name := ctx.Word()
+		if ctx.Debug == nil {
+			Throw(ErrUnsupported)
+		}
+		ctx.Debug.Unbreak(name)
+	},
+	"PROFILE-ON": func(ctx *AnnexiaForth, w *ForthWord) {
+		if ctx.Profiler() == nil {
+			ctx.AddHook(NewProfiler())
+		}
+	},
+	"PROFILE-OFF": func(ctx *AnnexiaForth, w *ForthWord) {
+		if p := ctx.Profiler(); p != nil {
+			ctx.RemoveHook(p)
+		}
+	},
+	"PROFILE-REPORT": func(ctx *AnnexiaForth, w *ForthWord) {
+		p := ctx.Profiler()
+		if p == nil {
+			Throw(ErrUnsupported)
+		}
+		var b strings.Builder
+		p.WriteReport(&b)
+		ctx.write(b.String())
+	},
+
+	"SAVE-IMAGE": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		if err := ctx.SaveImageFile(ctx.str(ctx.pop(), n)); err != nil {
+			Throw(ErrFileIO)
+		}
+	},
+	"LOAD-IMAGE": func(ctx *AnnexiaForth, w *ForthWord) {
+		n := ctx.pop()
+		if err := ctx.LoadImageFile(ctx.str(ctx.pop(), n)); err != nil {
+			Throw(ErrFileIO)
+		}
+	},
+
+	"CATCH": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.push(int(ctx.Catch(ctx.pop())))
+	},
+	"THROW": func(ctx *AnnexiaForth, w *ForthWord) {
+		Throw(Exception(ctx.pop()))
+	},
+	"ABORT": func(ctx *AnnexiaForth, w *ForthWord) {
+		Throw(ErrAbort)
+	},
+
+	"SPACES": func(ctx *AnnexiaForth, w *ForthWord) {
+		if n := ctx.pop(); n > 0 {
+			ctx.write(strings.Repeat(" ", n))
+		}
+	},
+	"LITSTRING": func(ctx *AnnexiaForth, w *ForthWord) {
+		addr := ctx.RSP.next()
+		ctx.push(addr, ctx.RSP.next())
+	},
+	"TELL": opType,
+	"TYPE": opType,
+	"COUNT": func(ctx *AnnexiaForth, w *ForthWord) {
+		addr := ctx.pop()
+		ctx.push(addr + 1, int(ctx.bytes(addr, 1)[0]))
+	},
+	`S"`: opString,
+	`S\"`: opString,
+	`C"`: opString,
+	`."`: func(ctx *AnnexiaForth, w *ForthWord) {
+		s := ctx.Parse('"')
+		if ctx.State != 0 {
+			ctx.compile(ctx.code("LITSTRING"), ctx.stash(s), len(s), ctx.code("TELL"))
+		} else {
+			ctx.write(s)
+		}
+	},
+	".(": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.write(ctx.Parse(')'))
+	},
+	"EMIT": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.write(fmt.Sprintf("%c", ctx.pop()))
+	},
+	".": func(ctx *AnnexiaForth, w *ForthWord) {
+		ctx.write(strconv.FormatInt(int64(ctx.pop()), ctx.radix()) + " ")
+	},
+}
+
+func opDo(ctx *AnnexiaForth, w *ForthWord) {
+	exit := ctx.RSP.POS
+	exit += ctx.RSP.next()
+	index, limit := ctx.pop(), ctx.pop()
+	if w.Name == "(?DO)" && index == limit {
+		ctx.RSP.POS = exit
+		return
+	}
+	ctx.rpush(exit, limit, index)
+}
+
+func opLoop(ctx *AnnexiaForth, w *ForthWord) {
+	ctx.rneed(3)
+	n := 1
+	if w.Name == "(+LOOP)" {
+		n = ctx.pop()
+	}
+	limit, index := ctx.RStack[len(ctx.RStack)-2].Code, ctx.RStack[len(ctx.RStack)-1].Code
+	d := index - limit
+	if n >= 0 && d < 0 && d + n >= 0 || n < 0 && d >= 0 && d + n < 0 {
+		ctx.RStack = ctx.RStack[:len(ctx.RStack)-3]
+		ctx.RSP.next()
+	} else {
+		ctx.RStack[len(ctx.RStack)-1].Code = index + n
+		ctx.branch()
+	}
+}
+
+func opIf(ctx *AnnexiaForth, w *ForthWord) {
+	ctx.compile(ctx.code("0BRANCH"))
+	ctx.push(ctx.mark())
+	ctx.compile(0)
+	if w.Name == "WHILE" {
+		ctx.need(2)
+		ctx.DStack[len(ctx.DStack)-1], ctx.DStack[len(ctx.DStack)-2] =
+			ctx.DStack[len(ctx.DStack)-2], ctx.DStack[len(ctx.DStack)-1]
+	}
+}
+
+func opAgain(ctx *AnnexiaForth, w *ForthWord) {
+	ctx.compile(ctx.code("BRANCH"))
+	ctx.compile(ctx.cpop() - ctx.mark())
+	if w.Name == "REPEAT" {
+		ctx.resolve(ctx.cpop())
+	}
+}
+
+func opCompileDo(ctx *AnnexiaForth, w *ForthWord) {
+	ctx.compile(ctx.code("(" + w.Name + ")"))
+	ctx.push(ctx.mark())
+	ctx.compile(0)
+}
+
+func opCompileLoop(ctx *AnnexiaForth, w *ForthWord) {
+	orig := ctx.cpop()
+	ctx.compile(ctx.code("(" + w.Name + ")"))
+	ctx.compile(orig + 1 - ctx.mark())
+	ctx.resolve(orig)
+}
+
+func opVariable(ctx *AnnexiaForth, w *ForthWord) {
+	n := 1
+	if w.Name == "2VARIABLE" {
+		n = 2
+	}
+	name := ctx.Word()
+	ctx.allot(ctx.aligned(ctx.Here) - ctx.Here)
+	addr := ctx.allot(n * ctx.CellSize)
+	for i := 0; i < n; i++ {
+		ctx.store(addr + i * ctx.CellSize, 0)
+	}
+	ctx.create(name, ctx.code("DOVAR"), addr)
+}
+
+func opTo(ctx *AnnexiaForth, w *ForthWord) {
+	name := ctx.Word()
+	if name == "" {
+		Throw(ErrZeroLengthName)
+	}
+	_, word := ctx.Lookup(name)
+	if word == nil {
+		Throw(ErrUndefinedWord)
+	}
+	if word.Native || len(word.Words) < 2 || word.Words[0] != ctx.code("DOVAL") {
+		Throw(ErrInvalidName)
+	}
+
+	store := "!"
+	if w.Name == "+TO" {
+		store = "+!"
+	}
+	if ctx.State != 0 {
+		ctx.compile(ctx.code("LIT"), word.Words[1], ctx.code(store))
+	} else if store == "!" {
+		ctx.store(word.Words[1], ctx.pop())
+	} else {
+		ctx.store(word.Words[1], ctx.fetch(word.Words[1]) + ctx.pop())
+	}
+}
+
+func opType(ctx *AnnexiaForth, w *ForthWord) {
+	n := ctx.pop()
+	ctx.write(ctx.str(ctx.pop(), n))
+}
+
+func opString(ctx *AnnexiaForth, w *ForthWord) {
+	var s string
+	if w.Name == `S\"` {
+		s = ctx.parseEscaped()
+	} else {
+		s = ctx.Parse('"')
+	}
+
+	if w.Name == `C"` {
+		if len(s) > 255 {
+			Throw(ErrStringOverflow)
+		}
+		addr := ctx.stash(string([]byte{byte(len(s))}) + s)
+		if ctx.State != 0 {
+			ctx.compile(ctx.code("LIT"), addr)
+		} else {
+			ctx.push(addr)
+		}
+		return
+	}
+
+	addr := ctx.stash(s)
+	if ctx.State != 0 {
+		ctx.compile(ctx.code("LITSTRING"), addr, len(s))
+	} else {
+		ctx.push(addr, len(s))
+	}
+}
+
+// RootHandler runs the primitive called w.Name. Words defined on a page
+// with Ops are bound to their primitive directly and never get here. Names
+// without a primitive throw ErrUnsupported.
+func RootHandler(ctx *AnnexiaForth, w *ForthWord) {
+	if op, ok := primitives[w.Name]; ok {
+		op(ctx, w)
+		return
+	}
+	Throw(ErrUnsupported)
+}
@@ -0,0 +1,95 @@
+package annexia
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Hook is told about execution as it happens. Hooks are called in the
+// order they were added and may read but should not change the VM.
+type Hook interface {
+	// Enter is called once a colon definition or DOES> code is entered.
+	// For DOES> code word is the child of the defining word.
+	Enter(f *AnnexiaForth, word *ForthWord)
+	// Exit is called as word returns.
+	Exit(f *AnnexiaForth, word *ForthWord)
+	// Primitive is called before a native word executes, and before a
+	// word made by VARIABLE, CONSTANT or VALUE, which runs a primitive.
+	Primitive(f *AnnexiaForth, word *ForthWord)
+	// Compile is called with the cells appended to the latest definition.
+	Compile(f *AnnexiaForth, cells []int)
+	// Error is called when an exception escapes the outer interpreter.
+	Error(f *AnnexiaForth, err *Error)
+}
+
+// NopHook does nothing. Embed it to implement only part of Hook.
+type NopHook struct{}
+
+func (NopHook) Enter(f *AnnexiaForth, word *ForthWord)     {}
+func (NopHook) Exit(f *AnnexiaForth, word *ForthWord)      {}
+func (NopHook) Primitive(f *AnnexiaForth, word *ForthWord) {}
+func (NopHook) Compile(f *AnnexiaForth, cells []int)       {}
+func (NopHook) Error(f *AnnexiaForth, err *Error)          {}
+
+// AddHook registers h. A VM without hooks does not look for them beyond
+// checking for a nil slice.
+func (f *AnnexiaForth) AddHook(h Hook) {
+	f.hooks = append(f.hooks, h)
+}
+
+// RemoveHook unregisters h.
+func (f *AnnexiaForth) RemoveHook(h Hook) {
+	for i, g := range f.hooks {
+		if g == h {
+			f.hooks = append(f.hooks[:i:i], f.hooks[i+1:]...)
+			break
+		}
+	}
+	if len(f.hooks) == 0 {
+		f.hooks = nil
+	}
+}
+
+// Tracer is a Hook that writes an indented call tree to W, with the data
+// stack as each word starts and as each definition returns.
+type Tracer struct {
+	W     io.Writer
+	depth int
+}
+
+// NewTracer returns a Tracer writing to w.
+func NewTracer(w io.Writer) (*Tracer) {
+	return &Tracer{W: w}
+}
+
+func (t *Tracer) indent() (string) {
+	return strings.Repeat("  ", t.depth)
+}
+
+func (t *Tracer) Enter(f *AnnexiaForth, word *ForthWord) {
+	fmt.Fprintf(t.W, "%s: %s %v\n", t.indent(), word.Name, f.DStack)
+	t.depth++
+}
+
+func (t *Tracer) Exit(f *AnnexiaForth, word *ForthWord) {
+	if t.depth > 0 {
+		t.depth--
+	}
+	fmt.Fprintf(t.W, "%s; %s %v\n", t.indent(), word.Name, f.DStack)
+}
+
+func (t *Tracer) Primitive(f *AnnexiaForth, word *ForthWord) {
+	fmt.Fprintf(t.W, "%s%s %v\n", t.indent(), word.Name, f.DStack)
+}
+
+func (t *Tracer) Compile(f *AnnexiaForth, cells []int) {
+	fmt.Fprintf(t.W, "%scompile %v\n", t.indent(), cells)
+}
+
+// Error also resets the indentation, since the words it unwound never
+// exited.
+func (t *Tracer) Error(f *AnnexiaForth, err *Error) {
+	t.depth = 0
+	fmt.Fprintf(t.W, "error %v\n", err)
+}
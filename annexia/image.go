@@ -0,0 +1,260 @@
+package annexia
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// An image starts with imageMagic and a little endian uint16 version and
+// ends with the CRC-32 of everything in between. The body is varints:
+//
+//	cell size, latest, here, base, current, wordlists, order count, order
+//	page count
+//	per page: offset, word count
+//	per word: name, flags, wordlist, cell count, cells
+//	data space length, data space bytes
+const (
+	imageMagic   = "ANNX"
+	imageVersion = 2
+)
+
+const (
+	imageImmediate = 1 << iota
+	imageHidden
+	imageCompileOnly
+	imageNative
+)
+
+// ErrBadImage is returned by LoadImage for an image that is truncated,
+// corrupt or from another version.
+var ErrBadImage = errors.New("bad image")
+
+// SaveImage writes the dictionary, data space and the variables needed to
+// resume it to w. Native words are saved by name only.
+func (f *AnnexiaForth) SaveImage(w io.Writer) (error) {
+	var b bytes.Buffer
+	put := func(v int) {
+		var buf [binary.MaxVarintLen64]byte
+		b.Write(buf[:binary.PutVarint(buf[:], int64(v))])
+	}
+
+	put(f.CellSize)
+	put(f.Latest)
+	put(f.Here)
+	put(f.Base)
+	put(f.Current)
+	put(f.Wordlists)
+	put(len(f.Order))
+	for _, wid := range f.Order {
+		put(wid)
+	}
+
+	var pages []*ForthPage
+	for p := f.Pages; p != nil; p = p.Parent {
+		pages = append([]*ForthPage{p}, pages...)
+	}
+	put(len(pages))
+	for _, p := range pages {
+		put(p.Offset)
+		put(len(p.Dict))
+		for _, word := range p.Dict {
+			put(len(word.Name))
+			b.WriteString(word.Name)
+			put(imageFlags(word))
+			put(word.List)
+			put(len(word.Words))
+			for _, c := range word.Words {
+				put(c)
+			}
+		}
+	}
+	put(len(f.Pages.Memory))
+	b.Write(f.Pages.Memory)
+
+	var head [6]byte
+	copy(head[:], imageMagic)
+	binary.LittleEndian.PutUint16(head[4:], imageVersion)
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc32.ChecksumIEEE(b.Bytes()))
+
+	for _, chunk := range [][]byte{head[:], b.Bytes(), sum[:]} {
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadImage replaces the dictionary and data space of f with an image
+// written by SaveImage. Native words are bound to the primitive of the
+// same name, or to a native word f already has, so host words added with
+// Define must be defined again before loading.
+func (f *AnnexiaForth) LoadImage(r io.Reader) (error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) < 10 || string(data[:4]) != imageMagic {
+		return ErrBadImage
+	}
+	if v := binary.LittleEndian.Uint16(data[4:]); v != imageVersion {
+		return fmt.Errorf("%w: version %d", ErrBadImage, v)
+	}
+	body := data[6:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return fmt.Errorf("%w: checksum mismatch", ErrBadImage)
+	}
+
+	br := bytes.NewReader(body)
+	get := func() int {
+		v, e := binary.ReadVarint(br)
+		if e != nil && err == nil {
+			err = ErrBadImage
+		}
+		return int(v)
+	}
+	count := func() int {
+		n := get()
+		if n < 0 || n > br.Len() {
+			err, n = ErrBadImage, 0
+		}
+		return n
+	}
+
+	cellSize, latest, here, base := get(), get(), get(), get()
+	current, wordlists := get(), get()
+	order := make([]int, count())
+	for i := range order {
+		order[i] = get()
+	}
+
+	root := f.root()
+
+	var top *ForthPage
+	for i, n := 0, count(); i < n && err == nil; i++ {
+		p := &ForthPage{Parent: top, Offset: get(), Handler: root.Handler}
+		if top == nil {
+			p.Ops = root.Ops
+		}
+		if top != nil && p.Offset != top.Offset + len(top.Dict) || top == nil && p.Offset != 0 {
+			return fmt.Errorf("%w: page offset %d", ErrBadImage, p.Offset)
+		}
+
+		for j, words := 0, count(); j < words && err == nil; j++ {
+			name := make([]byte, count())
+			io.ReadFull(br, name)
+			flags, list := get(), get()
+			word := &ForthWord{
+				Name:        string(name),
+				Page:        p,
+				Immediate:   flags & imageImmediate != 0,
+				Hidden:      flags & imageHidden != 0,
+				CompileOnly: flags & imageCompileOnly != 0,
+				Native:      flags & imageNative != 0,
+				List:        list,
+			}
+			for k, cells := 0, count(); k < cells; k++ {
+				word.Words = append(word.Words, get())
+			}
+			if word.Native {
+				if word.Exec = f.native(root, word.Name); word.Exec == nil {
+					return fmt.Errorf("%w: unknown native word %s", ErrBadImage, word.Name)
+				}
+			}
+			p.Add(word)
+		}
+		top = p
+	}
+
+	mem := make([]byte, count())
+	io.ReadFull(br, mem)
+	if err != nil {
+		return err
+	}
+	if top == nil || len(mem) < AddrData || cellSize < 1 || cellSize > 8 || len(order) > SearchOrderSize {
+		return ErrBadImage
+	}
+	// Nothing of f is replaced unless the variables are usable with the
+	// dictionary and data space they came with.
+	switch {
+	case latest < 0 || latest >= top.Offset + len(top.Dict):
+		return fmt.Errorf("%w: latest %d", ErrBadImage, latest)
+	case here < AddrData || here > len(mem):
+		return fmt.Errorf("%w: here %d", ErrBadImage, here)
+	case base < 2 || base > 36:
+		return fmt.Errorf("%w: base %d", ErrBadImage, base)
+	case wordlists < 1 || current < 0 || current >= wordlists:
+		return fmt.Errorf("%w: current %d", ErrBadImage, current)
+	}
+	for _, wid := range order {
+		if wid < 0 || wid >= wordlists {
+			return fmt.Errorf("%w: wordlist %d in search order", ErrBadImage, wid)
+		}
+	}
+	top.Memory = mem
+
+	f.Pages, f.Latest, f.Here, f.Base, f.CellSize = top, latest, here, base, cellSize
+	f.bind(f.root())
+	f.Order, f.Current, f.Wordlists = order, current, wordlists
+	f.State = 0
+	return nil
+}
+
+// native returns the handler for a saved native word called name.
+func (f *AnnexiaForth) native(root *ForthPage, name string) (ForthHandle) {
+	if op, ok := root.Ops[name]; ok {
+		return op
+	}
+	for p := f.Pages; p != nil; p = p.Parent {
+		for _, word := range p.Dict {
+			if word.Native && word.Name == name {
+				return word.Exec
+			}
+		}
+	}
+	return nil
+}
+
+func imageFlags(w *ForthWord) (flags int) {
+	if w.Immediate {
+		flags |= imageImmediate
+	}
+	if w.Hidden {
+		flags |= imageHidden
+	}
+	if w.CompileOnly {
+		flags |= imageCompileOnly
+	}
+	if w.Native {
+		flags |= imageNative
+	}
+	return
+}
+
+// SaveImageFile writes an image of f to the file at path.
+func (f *AnnexiaForth) SaveImageFile(path string) (error) {
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = f.SaveImage(fd); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// LoadImageFile loads the image in the file at path.
+func (f *AnnexiaForth) LoadImageFile(path string) (error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	return f.LoadImage(fd)
+}
@@ -0,0 +1,782 @@
+package annexia
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"sour.is/x/log"
+)
+
+func (f *AnnexiaForth) Read(in string) {
+	if err := f.Eval(in); err != nil {
+		log.Error(err)
+	}
+}
+
+// Eval interprets in and returns the exception that escaped it as an
+// *Error. The stacks are reset like ABORT when that happens.
+func (f *AnnexiaForth) Eval(in string) (error) {
+	return f.eval(context.Background(), strings.NewReader(in), -1)
+}
+
+// EvalContext is Eval that stops with ErrInterrupt once ctx is done. The
+// *Error then also wraps the error of ctx.
+func (f *AnnexiaForth) EvalContext(ctx context.Context, in string) (error) {
+	return f.eval(ctx, strings.NewReader(in), -1)
+}
+
+// EvalReader is Eval for source read from r. REFILL reads further lines
+// of r like it would from the user input device.
+func (f *AnnexiaForth) EvalReader(r io.Reader) (error) {
+	return f.eval(context.Background(), r, 0)
+}
+
+// Run interprets Stdin until it is exhausted. An error is written to
+// Stdout and aborts the rest of its line, like ABORT, and Run carries on
+// with the next one.
+func (f *AnnexiaForth) Run() (error) {
+	return f.RunContext(context.Background())
+}
+
+// RunContext is Run that stops with ErrInterrupt once ctx is done.
+func (f *AnnexiaForth) RunContext(ctx context.Context) (error) {
+	r := bufio.NewReader(f.Stdin)
+	for {
+		err := f.eval(ctx, r, 0)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		fmt.Fprintln(f.Stdout, err)
+	}
+}
+
+func (f *AnnexiaForth) eval(ctx context.Context, r io.Reader, id int) (err error) {
+	f.steps, f.output = 0, 0
+	prev := f.ctx
+	f.ctx = ctx
+	defer func() {
+		f.ctx = prev
+		if r := recover(); r != nil {
+			e, ok := f.wrap(r).(*Error)
+			if !ok {
+				panic(r)
+			}
+			f.Abort()
+			if ctx.Err() != nil && errors.Is(e, ErrInterrupt) {
+				e.Err = fmt.Errorf("%w: %w", ErrInterrupt, ctx.Err())
+			}
+			err = e
+		}
+	}()
+
+	f.outer(r, id)
+	return nil
+}
+
+// Push pushes v onto the data stack.
+func (f *AnnexiaForth) Push(v ...int) (error) {
+	if len(f.DStack) + len(v) > f.DStackSize {
+		return ErrStackOverflow
+	}
+	f.push(v...)
+	return nil
+}
+
+// Pop removes and returns the top of the data stack.
+func (f *AnnexiaForth) Pop() (int, error) {
+	if len(f.DStack) == 0 {
+		return 0, ErrStackUnderflow
+	}
+	return f.pop(), nil
+}
+
+// Fetch returns the cell at addr in the data space.
+func (f *AnnexiaForth) Fetch(addr int) (v int, err error) {
+	err = try(func() { v = f.fetch(addr) })
+	return
+}
+
+// Store writes v to the cell at addr in the data space.
+func (f *AnnexiaForth) Store(addr, v int) (error) {
+	return try(func() { f.store(addr, v) })
+}
+
+// Bytes returns the n bytes of data space at addr. Writing to them writes
+// to the data space, except for the cells of the built-in variables,
+// which only hold a copy of their values.
+func (f *AnnexiaForth) Bytes(addr, n int) (b []byte, err error) {
+	err = try(func() { b = f.bytes(addr, n) })
+	return
+}
+
+// Str returns the string of n characters at addr, like the c-addr u pair
+// S" leaves.
+func (f *AnnexiaForth) Str(addr, n int) (s string, err error) {
+	err = try(func() { s = f.str(addr, n) })
+	return
+}
+
+// Transaction calls fn with new definitions going to a page of their own.
+// If fn returns an error or panics the page is dropped, discarding the
+// words fn defined and its changes to the data space. FORGET and markers
+// may not reach below the page while fn runs, since that would change
+// pages the transaction cannot restore.
+func (f *AnnexiaForth) Transaction(fn func() error) (err error) {
+	pages, latest, here, base, state := f.Pages, f.Latest, f.Here, f.Base, f.State
+	order, current, floor := append([]int(nil), f.Order...), f.Current, f.floor
+	f.Pages = AddPage(pages, f.root().Handler)
+	f.floor = f.Pages
+
+	ok := false
+	defer func() {
+		f.floor = floor
+		if !ok {
+			f.Pages, f.Latest, f.Here, f.Base, f.State = pages, latest, here, base, state
+			f.Order, f.Current = order, current
+		}
+	}()
+
+	err = fn()
+	ok = err == nil
+	return
+}
+
+// Lookup finds the visible word called name in the search order. It
+// returns a nil word if there is none.
+func (f *AnnexiaForth) Lookup(name string) (int, *ForthWord) {
+	for i := len(f.Order)-1; i >= 0; i-- {
+		if code, word := f.Pages.Search(name, f.Order[i]); word != nil {
+			return code, word
+		}
+	}
+	return 0, nil
+}
+
+// Number converts name to a number in the current BASE. The outer
+// interpreter only tries it once the dictionary lookup has failed.
+func (f *AnnexiaForth) Number(name string) (int, bool) {
+	return to_int(name, f.radix())
+}
+
+// radix returns BASE for number conversion. It throws ErrInvalidNumber
+// unless BASE is between 2 and 36.
+func (f *AnnexiaForth) radix() (int) {
+	if f.Base < 2 || f.Base > 36 {
+		Throw(ErrInvalidNumber)
+	}
+	return f.Base
+}
+
+// outer runs the outer interpreter over r until it is exhausted. The
+// previous input source is restored afterwards so it may nest.
+func (f *AnnexiaForth) outer(r io.Reader, id int) {
+	input, sourceID, tib, toIn, line, rsp := f.Input, f.SourceID, f.TIB, f.ToIn, f.Line, f.RSP
+	defer func() {
+		r := recover()
+		if r != nil {
+			r = f.wrap(r)
+		}
+
+		f.Input, f.SourceID, f.TIB, f.ToIn, f.Line, f.RSP = input, sourceID, tib, toIn, line, rsp
+		f.mirror()
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	f.Input, f.SourceID, f.TIB, f.ToIn, f.Line = bufio.NewReader(r), id, "", 0, 0
+	f.RSP = WordPtr{}
+
+	for f.Refill() {
+		for name := f.Word(); name != ""; name = f.Word() {
+			if f.interrupted() {
+				Throw(ErrInterrupt)
+			}
+			f.interpret(name)
+			for f.RSP.Word != nil {
+				f.next()
+			}
+		}
+	}
+}
+
+// interpret executes or compiles a single name according to STATE.
+func (f *AnnexiaForth) interpret(name string) {
+	f.name, f.W = name, WordPtr{}
+	code, word := f.Lookup(name)
+
+	if word != nil {
+		if f.State != 0 && !word.Immediate {
+			f.compile(code)
+		} else if f.State == 0 && word.CompileOnly {
+			Throw(ErrCompileOnly)
+		} else {
+			f.exec(code, word)
+		}
+	} else if v, ok := f.Number(name); ok {
+		if f.State != 0 {
+			f.compile(f.code("LIT"), v)
+		} else {
+			f.push(v)
+		}
+	} else {
+		Throw(ErrUndefinedWord)
+	}
+}
+
+// Refill reads the next line of the input source into the TIB.
+func (f *AnnexiaForth) Refill() (bool) {
+	if f.Input == nil {
+		return false
+	}
+
+	line, err := f.Input.ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	f.TIB, f.ToIn = strings.TrimRight(line, "\r\n"), 0
+	f.Line++
+	f.mirror()
+
+	return true
+}
+
+// mirror copies the TIB into memory so SOURCE and PARSE can address it.
+func (f *AnnexiaForth) mirror() {
+	copy(f.bytes(AddrTIB, TIBSize), f.TIB)
+}
+
+// Word skips leading whitespace and parses the next name from the TIB.
+func (f *AnnexiaForth) Word() (string) {
+	f.col = f.skip(' ')
+	return f.Parse(' ')
+}
+
+// Parse returns the characters up to delim and moves >IN past it. A space
+// delimiter matches any whitespace.
+func (f *AnnexiaForth) Parse(delim byte) (string) {
+	start := f.skip(0)
+	for f.ToIn < len(f.TIB) && !isDelim(f.TIB[f.ToIn], delim) {
+		f.ToIn++
+	}
+	name := f.TIB[start:f.ToIn]
+	if f.ToIn < len(f.TIB) {
+		f.ToIn++
+	}
+
+	return name
+}
+
+// escapes are the characters S\" translates after a backslash.
+var escapes = map[byte]string{
+	'a': "\a", 'b': "\b", 'e': "\x1b", 'f': "\f", 'l': "\n", 'm': "\r\n",
+	'n': "\n", 'q': `"`, 'r': "\r", 't': "\t", 'v': "\v", 'z': "\x00",
+}
+
+// parseEscaped parses up to an unescaped " like S\" and translates the
+// escape sequences in it.
+func (f *AnnexiaForth) parseEscaped() (string) {
+	var b strings.Builder
+	f.skip(0)
+	for f.ToIn < len(f.TIB) {
+		c := f.TIB[f.ToIn]
+		f.ToIn++
+		if c == '"' {
+			break
+		}
+		if c != '\\' || f.ToIn >= len(f.TIB) {
+			b.WriteByte(c)
+			continue
+		}
+
+		c = f.TIB[f.ToIn]
+		f.ToIn++
+		if s, ok := escapes[c]; ok {
+			b.WriteString(s)
+		} else if c == 'x' && f.ToIn + 2 <= len(f.TIB) {
+			v, err := strconv.ParseUint(f.TIB[f.ToIn:f.ToIn+2], 16, 8)
+			if err != nil {
+				Throw(ErrInvalidNumber)
+			}
+			b.WriteByte(byte(v))
+			f.ToIn += 2
+		} else {
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// stash stores s for S" and friends. While compiling it goes in the data
+// space, otherwise in whichever transient buffer was used least recently.
+func (f *AnnexiaForth) stash(s string) (int) {
+	var addr int
+	if f.State != 0 {
+		addr = f.allot(len(s))
+	} else {
+		if len(s) > StrSize {
+			Throw(ErrStringOverflow)
+		}
+		addr = AddrStr + f.sbuf * StrSize
+		f.sbuf ^= 1
+	}
+	copy(f.bytes(addr, len(s)), s)
+
+	return addr
+}
+
+// skip moves >IN past any leading delim characters and returns it.
+func (f *AnnexiaForth) skip(delim byte) (int) {
+	if f.ToIn > len(f.TIB) {
+		f.ToIn = len(f.TIB)
+	}
+	for delim != 0 && f.ToIn < len(f.TIB) && isDelim(f.TIB[f.ToIn], delim) {
+		f.ToIn++
+	}
+
+	return f.ToIn
+}
+
+// Key returns the next character of the input source, refilling the TIB
+// at the end of each line. It returns -1 at the end of input.
+func (f *AnnexiaForth) Key() (int) {
+	if f.ToIn >= len(f.TIB) {
+		if !f.Refill() {
+			return -1
+		}
+		return '\n'
+	}
+	c := f.TIB[f.ToIn]
+	f.ToIn++
+
+	return int(c)
+}
+
+func isDelim(c, delim byte) (bool) {
+	if delim == ' ' {
+		return unicode.IsSpace(rune(c))
+	}
+	return c == delim
+}
+
+// compile appends cells to the definition of the latest word.
+func (f *AnnexiaForth) compile(cells ...int) {
+	word := f.word(f.Latest)
+	word.Words = append(word.Words, cells...)
+	for _, h := range f.hooks {
+		h.Compile(f, cells)
+	}
+}
+
+// mark returns the position of the next cell compile will append.
+func (f *AnnexiaForth) mark() (int) {
+	word := f.word(f.Latest)
+	return len(word.Words)
+}
+
+// cpop pops a control-flow position left by a compiling word and checks
+// that it lies inside the definition.
+func (f *AnnexiaForth) cpop() (int) {
+	orig := f.pop()
+	if orig < 1 || orig >= f.mark() {
+		Throw(ErrControlMismatch)
+	}
+	return orig
+}
+
+// resolve patches the branch offset at orig to jump to Here.
+func (f *AnnexiaForth) resolve(orig int) {
+	word := f.word(f.Latest)
+	word.Words[orig] = len(word.Words) - orig
+}
+
+// word returns the word for code or throws if there is none.
+func (f *AnnexiaForth) word(code int) (*ForthWord) {
+	_, word := f.Pages.FindCode(code)
+	if word == nil {
+		Throw(ErrUndefinedWord)
+	}
+	return word
+}
+
+// create adds a word called name with the given Words and makes it the
+// latest definition.
+func (f *AnnexiaForth) create(name string, words ...int) (*ForthWord) {
+	if name == "" {
+		Throw(ErrZeroLengthName)
+	}
+	if f.MaxWords > 0 && f.Pages.Offset + len(f.Pages.Dict) >= f.MaxWords {
+		Throw(ErrDictionaryOverflow)
+	}
+	word := &ForthWord{Name: strings.ToUpper(name), Page: f.Pages, List: f.Current, Words: words}
+	f.Pages.Add(word)
+	f.Latest = f.Pages.Offset + len(f.Pages.Dict) - 1
+	return word
+}
+
+// body returns the data field address of a word defined by CREATE.
+func (f *AnnexiaForth) body(word *ForthWord) (int) {
+	if word.Native || len(word.Words) < 2 {
+		Throw(ErrNotCreated)
+	}
+	if c := word.Words[0]; c != f.code("DOVAR") && c != f.code("DODOES") {
+		Throw(ErrNotCreated)
+	}
+	return word.Words[1]
+}
+
+// forget drops the word at code and everything defined after it, along
+// with any pages added since, and moves HERE back to here. The data space
+// keeps its contents. Words of the root page and words below a running
+// Transaction cannot be forgotten.
+func (f *AnnexiaForth) forget(code, here int) {
+	_, word := f.Pages.FindCode(code)
+	if word == nil {
+		Throw(ErrUndefinedWord)
+	}
+	p := word.Page
+	if p.Parent == nil || f.floor != nil && p.Offset < f.floor.Offset {
+		Throw(ErrInvalidForget)
+	}
+
+	p.Memory = append(p.Memory[:0], f.Pages.Memory...)
+	p.Truncate(code - p.Offset)
+	f.Pages = p
+	f.Latest = p.Offset + len(p.Dict) - 1
+	f.Here = min(f.Here, here)
+}
+
+// enter pushes RSP and continues threading at wp.
+func (f *AnnexiaForth) enter(wp WordPtr) {
+	if len(f.RStack) >= f.RStackSize {
+		Throw(ErrReturnOverflow)
+	}
+	f.RStack = append(f.RStack, f.RSP)
+	f.RSP = wp
+	for _, h := range f.hooks {
+		h.Enter(f, wp.hooked())
+	}
+}
+
+// leave returns from the definition at RSP to the one that entered it.
+func (f *AnnexiaForth) leave() {
+	f.rneed(1)
+	for _, h := range f.hooks {
+		h.Exit(f, f.RSP.hooked())
+	}
+	f.RSP, f.RStack = f.RStack[len(f.RStack)-1], f.RStack[:len(f.RStack)-1]
+}
+
+// hooked returns the word hooks are told a frame belongs to.
+func (wp WordPtr) hooked() (*ForthWord) {
+	if wp.child != nil {
+		return wp.child
+	}
+	return wp.Word
+}
+
+// code returns the code of the named word of the root page. The compiler
+// finds its own words with it, so user definitions that shadow them, in
+// any wordlist, cannot change what it compiles.
+func (f *AnnexiaForth) code(name string) (int) {
+	return f.internals[name]
+}
+
+// bind caches the codes of the words of root for code.
+func (f *AnnexiaForth) bind(root *ForthPage) {
+	f.internals = make(map[string]int, len(root.Dict))
+	for i, word := range root.Dict {
+		f.internals[word.Name] = root.Offset + i
+	}
+}
+
+// root returns the page holding the primitives.
+func (f *AnnexiaForth) root() (*ForthPage) {
+	p := f.Pages
+	for p.Parent != nil {
+		p = p.Parent
+	}
+	return p
+}
+
+// top returns the wordlist searched first.
+func (f *AnnexiaForth) top() (*int) {
+	if len(f.Order) == 0 {
+		Throw(ErrOrderUnderflow)
+	}
+	return &f.Order[len(f.Order)-1]
+}
+
+// compare pops two cells and pushes the flag of op applied to them.
+func (f *AnnexiaForth) compare(op func(a, b int) bool) {
+	b := f.pop()
+	f.push(flag(op(f.pop(), b)))
+}
+
+func flag(b bool) (int) {
+	if b {
+		return -1
+	}
+	return 0
+}
+
+// vars returns the field of f that backs the built-in variable at addr,
+// or nil if addr is not one.
+func (f *AnnexiaForth) vars(addr int) (*int) {
+	switch addr {
+	case AddrState:
+		return &f.State
+	case AddrHere:
+		return &f.Here
+	case AddrLatest:
+		return &f.Latest
+	case AddrS0:
+		return &f.SZ
+	case AddrBase:
+		return &f.Base
+	case AddrToIn:
+		return &f.ToIn
+	}
+	return nil
+}
+
+// interrupted reports whether the context of the running Eval is done.
+func (f *AnnexiaForth) interrupted() (bool) {
+	return f.ctx != nil && f.ctx.Err() != nil
+}
+
+// write prints s to Stdout, or as much of it as MaxOutput allows.
+func (f *AnnexiaForth) write(s string) {
+	if f.MaxOutput > 0 && f.output + len(s) > f.MaxOutput {
+		io.WriteString(f.Stdout, s[:f.MaxOutput - f.output])
+		f.output = f.MaxOutput
+		Throw(ErrOutputLimit)
+	}
+	f.output += len(s)
+	io.WriteString(f.Stdout, s)
+}
+
+// bytes returns the n bytes of data space at addr.
+func (f *AnnexiaForth) bytes(addr, n int) ([]byte) {
+	if addr < 0 || n < 0 || addr + n > len(f.Pages.Memory) {
+		Throw(ErrInvalidAddress)
+	}
+	if addr < AddrWord {
+		f.flush()
+	}
+	return f.Pages.Memory[addr:addr+n]
+}
+
+// flush writes the built-in variables, which live in fields of f, into
+// their cells so that byte access sees their values.
+func (f *AnnexiaForth) flush() {
+	for addr := AddrState; addr < AddrWord; addr += 8 {
+		v := *f.vars(addr)
+		for i := 0; i < f.CellSize; i++ {
+			f.Pages.Memory[addr+i] = byte(v >> (8 * i))
+		}
+	}
+}
+
+// reload reads the built-in variables back from their cells after a byte
+// write at addr that may have changed one.
+func (f *AnnexiaForth) reload(addr int) {
+	if addr >= AddrWord {
+		return
+	}
+	for cell := AddrState; cell < AddrWord; cell += 8 {
+		v := 0
+		for i := f.CellSize-1; i >= 0; i-- {
+			v = v << 8 | int(f.Pages.Memory[cell+i])
+		}
+		*f.vars(cell) = f.norm(v)
+	}
+}
+
+// fetch reads the little endian cell at addr.
+func (f *AnnexiaForth) fetch(addr int) (int) {
+	if v := f.vars(addr); v != nil {
+		return *v
+	}
+	b := f.bytes(addr, f.CellSize)
+	v := 0
+	for i := len(b)-1; i >= 0; i-- {
+		v = v << 8 | int(b[i])
+	}
+	return f.norm(v)
+}
+
+// store writes v to the cell at addr.
+func (f *AnnexiaForth) store(addr, v int) {
+	if p := f.vars(addr); p != nil {
+		*p = v
+		return
+	}
+	b := f.bytes(addr, f.CellSize)
+	for i := range b {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	f.reload(addr)
+}
+
+// str reads a string of n characters from addr.
+func (f *AnnexiaForth) str(addr, n int) (string) {
+	return string(f.bytes(addr, n))
+}
+
+// allot moves HERE by n bytes and returns its old value.
+func (f *AnnexiaForth) allot(n int) (int) {
+	here := f.Here
+	switch {
+	case here + n < AddrData:
+		Throw(ErrInvalidAddress)
+	case here + n > len(f.Pages.Memory):
+		Throw(ErrDictionaryOverflow)
+	}
+	f.Here += n
+	return here
+}
+
+// aligned rounds addr up to a multiple of the cell size.
+func (f *AnnexiaForth) aligned(addr int) (int) {
+	return (addr + f.CellSize - 1) / f.CellSize * f.CellSize
+}
+
+func (f *AnnexiaForth) push(v ...int) {
+	if len(f.DStack) + len(v) > f.DStackSize {
+		Throw(ErrStackOverflow)
+	}
+	for _, c := range v {
+		f.DStack = append(f.DStack, f.norm(c))
+	}
+}
+
+// norm truncates v to CellSize bytes and sign extends it.
+func (f *AnnexiaForth) norm(v int) (int) {
+	shift := bits.UintSize - 8 * f.CellSize
+	if shift <= 0 {
+		return v
+	}
+	return v << shift >> shift
+}
+func (f *AnnexiaForth) pop() (v int) {
+	f.need(1)
+	v, f.DStack = f.DStack[len(f.DStack)-1], f.DStack[:len(f.DStack)-1]
+	return
+}
+func (f *AnnexiaForth) need(n int) {
+	if len(f.DStack) < n {
+		Throw(ErrStackUnderflow)
+	}
+}
+func (f *AnnexiaForth) rneed(n int) {
+	if len(f.RStack) < n {
+		Throw(ErrReturnUnderflow)
+	}
+}
+func (f *AnnexiaForth) rpush(v ...int) {
+	if len(f.RStack) + len(v) > f.RStackSize {
+		Throw(ErrReturnOverflow)
+	}
+	for _, c := range v {
+		f.RStack = append(f.RStack, WordPtr{Code: c})
+	}
+}
+
+// execute runs word to completion. Colon definitions are entered through
+// their codeword and then threaded with NEXT until the matching EXIT pops
+// the empty WordPtr pushed on entry back into RSP.
+func (f *AnnexiaForth) execute(code int, word *ForthWord) {
+	rsp := f.RSP
+	f.RSP = WordPtr{}
+
+	f.exec(code, word)
+	for f.RSP.Word != nil {
+		f.next()
+	}
+	f.RSP = rsp
+}
+
+// next is the inner interpreter. It fetches the code at RSP, advances RSP
+// and executes it.
+func (f *AnnexiaForth) next() {
+	if f.steps++; f.MaxSteps > 0 && f.steps > f.MaxSteps {
+		Throw(ErrStepLimit)
+	}
+	if f.steps % checkInterval == 0 && f.interrupted() {
+		Throw(ErrInterrupt)
+	}
+	code := f.RSP.next()
+	_, word := f.RSP.Page.FindCode(code)
+	if word == nil {
+		Throw(ErrUndefinedWord)
+	}
+	f.exec(code, word)
+}
+
+// exec loads W with word and jumps through its codeword. Native words are
+// their own codeword, colon definitions hold it in Words[0].
+func (f *AnnexiaForth) exec(code int, word *ForthWord) {
+	if f.Debug != nil {
+		f.Debug.check(f, code, word)
+	}
+	f.W = WordPtr{Code: code, Word: word, Page: word.Page}
+
+	child := word
+	if !word.Native {
+		if len(word.Words) == 0 {
+			Throw(ErrUnsupported)
+		}
+		_, word = word.Page.FindCode(word.Words[0])
+		if word == nil {
+			Throw(ErrUndefinedWord)
+		}
+	}
+	// Definitions report themselves on Enter. Everything else, including
+	// the children of DOVAR, DOCON and DOVAL, runs as a primitive.
+	if f.hooks != nil && (child.Native || child.Words[0] != f.code("DOCOL") && child.Words[0] != f.code("DODOES")) {
+		for _, h := range f.hooks {
+			h.Primitive(f, child)
+		}
+	}
+	word.Exec(f, word)
+}
+
+// next returns the cell at POS and advances past it. It throws if POS is
+// outside the definition, or there is none because the outer interpreter
+// is running.
+func (wp *WordPtr) next() (int) {
+	if wp.Word == nil || wp.POS < 0 || wp.POS >= len(wp.Word.Words) {
+		Throw(ErrInvalidAddress)
+	}
+	code := wp.Word.Words[wp.POS]
+	wp.POS++
+
+	return code
+}
+
+// branch adds the offset at RSP to the position of that offset.
+func (f *AnnexiaForth) branch() {
+	off := f.RSP.next()
+	f.RSP.POS += off - 1
+}
+
+// cells returns the Words of the word a codeword is running for, which
+// must have at least n cells.
+func (f *AnnexiaForth) cells(n int) ([]int) {
+	if f.W.Word == nil || f.W.Word.Native || len(f.W.Word.Words) < n {
+		Throw(ErrUndefinedWord)
+	}
+	return f.W.Word.Words
+}
+
@@ -0,0 +1,59 @@
+package annexia
+
+import "io"
+
+// Option configures a VM created by NewForth.
+type Option func(*AnnexiaForth)
+
+// WithStdin sets the reader Run interprets as the user input device.
+func WithStdin(r io.Reader) Option {
+	return func(f *AnnexiaForth) { f.Stdin = r }
+}
+
+// WithStdout sets the writer for EMIT, . and other output words.
+func WithStdout(w io.Writer) Option {
+	return func(f *AnnexiaForth) { f.Stdout = w }
+}
+
+// WithCellSize sets the width of a cell in bytes. Values pushed on the
+// data stack are truncated and sign extended to this width.
+func WithCellSize(n int) Option {
+	return func(f *AnnexiaForth) {
+		if n > 0 && n <= 8 {
+			f.CellSize = n
+		}
+	}
+}
+
+// WithDataSize sets the size of the data space in bytes.
+func WithDataSize(n int) Option {
+	return func(f *AnnexiaForth) { f.DataSize = n }
+}
+
+// WithStackLimits sets the maximum depth of the data and return stacks.
+func WithStackLimits(data, ret int) Option {
+	return func(f *AnnexiaForth) { f.DStackSize, f.RStackSize = data, ret }
+}
+
+// WithStepLimit sets how many words the inner interpreter may execute in
+// one Eval before ErrStepLimit is thrown.
+func WithStepLimit(n int) Option {
+	return func(f *AnnexiaForth) { f.MaxSteps = n }
+}
+
+// WithOutputLimit sets how many bytes one Eval may write before
+// ErrOutputLimit is thrown.
+func WithOutputLimit(n int) Option {
+	return func(f *AnnexiaForth) { f.MaxOutput = n }
+}
+
+// WithDictLimit sets the most words the dictionary may hold, counting the
+// built-in ones.
+func WithDictLimit(n int) Option {
+	return func(f *AnnexiaForth) { f.MaxWords = n }
+}
+
+// WithHook registers h as AddHook does.
+func WithHook(h Hook) Option {
+	return func(f *AnnexiaForth) { f.AddHook(h) }
+}
@@ -0,0 +1,215 @@
+package annexia
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileEntry is what a Profiler measured for one word. Steps counts the
+// cells of the word executed by NEXT, one for a primitive, and Self the
+// time spent in them. TotalSteps and Total include the words it called.
+type ProfileEntry struct {
+	Word       *ForthWord
+	Calls      int
+	Steps      int
+	TotalSteps int
+	Self       time.Duration
+	Total      time.Duration
+
+	active int
+	leaf   bool
+}
+
+// Profiler is a Hook that counts calls, steps and time per word.
+type Profiler struct {
+	entries map[*ForthWord]*ProfileEntry
+	folded  map[string]time.Duration
+	stack   []profileFrame
+	cur     *ProfileEntry
+	path    string
+	last    time.Time
+}
+
+type profileFrame struct {
+	entry  *ProfileEntry
+	start  time.Time
+	rdepth int
+	steps  int
+	path   string
+}
+
+// NewProfiler returns an empty Profiler. Register it with AddHook.
+func NewProfiler() (*Profiler) {
+	p := &Profiler{}
+	p.Reset()
+	return p
+}
+
+// Reset discards everything measured so far.
+func (p *Profiler) Reset() {
+	p.entries = make(map[*ForthWord]*ProfileEntry)
+	p.folded = make(map[string]time.Duration)
+	p.stack, p.cur, p.path, p.last = nil, nil, "", time.Now()
+}
+
+func (p *Profiler) entry(word *ForthWord) (*ProfileEntry) {
+	e, ok := p.entries[word]
+	if !ok {
+		e = &ProfileEntry{Word: word}
+		p.entries[word] = e
+	}
+	return e
+}
+
+// tick charges the time since the last event to the word that ran in it,
+// and to its stack, and closes frames that CATCH unwound without an exit.
+func (p *Profiler) tick(f *AnnexiaForth) (time.Time) {
+	now := time.Now()
+	if p.cur != nil {
+		p.cur.Self += now.Sub(p.last)
+		p.folded[p.path] += now.Sub(p.last)
+	}
+	p.last = now
+	for len(p.stack) > 0 && p.stack[len(p.stack)-1].rdepth > len(f.RStack) {
+		p.pop(now)
+	}
+	return now
+}
+
+// step counts a cell executed by the definition on top of the stack and
+// returns the path of the word it ran for the folded stacks. The path is
+// listed there even if no time is measured in it.
+func (p *Profiler) step(name string) (path string) {
+	path = frameEscaper.Replace(name)
+	if len(p.stack) > 0 {
+		top := &p.stack[len(p.stack)-1]
+		top.entry.Steps++
+		top.steps++
+		path = top.path + ";" + path
+	}
+	p.folded[path] += 0
+	return path
+}
+
+func (p *Profiler) pop(now time.Time) {
+	top := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	e := top.entry
+	if e.active--; e.active == 0 {
+		e.Total += now.Sub(top.start)
+		e.TotalSteps += top.steps
+	}
+	p.cur, p.path = nil, ""
+	if len(p.stack) > 0 {
+		parent := &p.stack[len(p.stack)-1]
+		parent.steps += top.steps
+		p.cur, p.path = parent.entry, parent.path
+	}
+}
+
+func (p *Profiler) Enter(f *AnnexiaForth, word *ForthWord) {
+	now := p.tick(f)
+	path := p.step(word.Name)
+
+	e := p.entry(word)
+	e.Calls++
+	e.active++
+	p.stack = append(p.stack, profileFrame{entry: e, start: now, rdepth: len(f.RStack), path: path})
+	p.cur, p.path = e, path
+}
+
+func (p *Profiler) Exit(f *AnnexiaForth, word *ForthWord) {
+	now := p.tick(f)
+	if len(p.stack) > 0 {
+		p.pop(now)
+	}
+}
+
+func (p *Profiler) Primitive(f *AnnexiaForth, word *ForthWord) {
+	p.tick(f)
+	p.path = p.step(word.Name)
+
+	e := p.entry(word)
+	e.Calls++
+	e.Steps++
+	e.TotalSteps++
+	e.leaf = true
+	p.cur = e
+}
+
+func (p *Profiler) Compile(f *AnnexiaForth, cells []int) {}
+
+func (p *Profiler) Error(f *AnnexiaForth, err *Error) {
+	now := p.tick(f)
+	for len(p.stack) > 0 {
+		p.pop(now)
+	}
+}
+
+// Profiler returns the first Profiler registered with f, or nil.
+func (f *AnnexiaForth) Profiler() (*Profiler) {
+	for _, h := range f.hooks {
+		if p, ok := h.(*Profiler); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// Report returns an entry per word that ran, slowest first. The time of a
+// primitive, or of a word such as a VARIABLE that runs one, is also its
+// total.
+func (p *Profiler) Report() (report []ProfileEntry) {
+	for _, e := range p.entries {
+		r := *e
+		if r.leaf {
+			r.Total = r.Self
+		}
+		report = append(report, r)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i], report[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		if a.TotalSteps != b.TotalSteps {
+			return a.TotalSteps > b.TotalSteps
+		}
+		return a.Word.Name < b.Word.Name
+	})
+	return
+}
+
+// WriteReport writes Report to w as a table.
+func (p *Profiler) WriteReport(w io.Writer) {
+	fmt.Fprintf(w, "%8s %10s %11s %12s %12s  %s\n", "CALLS", "STEPS", "TOTAL-STEPS", "SELF", "TOTAL-TIME", "WORD")
+	for _, e := range p.Report() {
+		fmt.Fprintf(w, "%8d %10d %11d %12v %12v  %s\n", e.Calls, e.Steps, e.TotalSteps, e.Self, e.Total, e.Word.Name)
+	}
+}
+
+// frameEscaper keeps word names such as ; from splitting a folded frame.
+var frameEscaper = strings.NewReplacer("%", "%25", ";", "%3B")
+
+// WriteFolded writes the call stacks seen in the folded format used by
+// flame graph tools, one "OUTER;INNER;WORD weight" line per stack. The
+// weight is the time in microseconds spent in the innermost word there,
+// not counting the words it called. A ; or % in a word name is written as
+// %3B or %25.
+func (p *Profiler) WriteFolded(w io.Writer) (error) {
+	var paths []string
+	for path := range p.folded {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if _, err := fmt.Fprintf(w, "%s %d\n", path, p.folded[path].Microseconds()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package annexia
+
+import (
+	"strconv"
+	"strings"
+)
+
+// operands is how many inline cells follow each word that takes them.
+var operands = map[string]int{
+	"LIT": 1, "LITSTRING": 2, "BRANCH": 1, "0BRANCH": 1,
+	"(DO)": 1, "(?DO)": 1, "(LOOP)": 1, "(+LOOP)": 1, "'": 1,
+}
+
+// See returns source for the visible word called name that compiles back
+// to the same code. Only the addresses of string literals differ.
+func (f *AnnexiaForth) See(name string) (s string, err error) {
+	code, word := f.Lookup(name)
+	if word == nil {
+		return "", ErrUndefinedWord
+	}
+	err = try(func() { s = f.decompile(code, word) })
+	return
+}
+
+// decompile returns the source of word, whose code is code.
+func (f *AnnexiaForth) decompile(code int, word *ForthWord) (string) {
+	if word.Native {
+		return "( " + word.Name + " is a primitive )"
+	}
+	if len(word.Words) == 0 {
+		return "( " + word.Name + " has no code )"
+	}
+
+	num := func(v int) string { return strconv.FormatInt(int64(v), f.radix()) }
+	ws := word.Words
+	switch f.word(ws[0]).Name {
+	case "DOCOL":
+	case "DOCON":
+		if len(ws) == 3 {
+			return num(ws[1]) + " " + num(ws[2]) + " 2CONSTANT " + word.Name
+		}
+		return num(ws[1]) + " CONSTANT " + word.Name
+	case "DOVAL":
+		return num(f.fetch(ws[1])) + " VALUE " + word.Name
+	case "DOVAR":
+		return "CREATE " + word.Name + " ( data at " + num(ws[1]) + " )"
+	case "DOVOCAB":
+		return "VOCABULARY " + word.Name
+	case "DOMARKER":
+		return "MARKER " + word.Name
+	default:
+		return "( " + word.Name + " is defined by " + f.word(ws[0]).Name + " )"
+	}
+
+	// Find where each instruction starts, then turn the branches into
+	// the control structures that compiled them.
+	type insn struct {
+		pos  int
+		word *ForthWord
+	}
+	var insns []insn
+	at := map[int]int{}
+	for pos := 1; pos < len(ws); {
+		_, w := f.Pages.FindCode(ws[pos])
+		if w == nil {
+			w = &ForthWord{}
+		}
+		at[pos] = len(insns)
+		insns = append(insns, insn{pos, w})
+		pos += 1 + operands[w.Name]
+	}
+
+	// Replay the stack the compiler kept its control flow on. Each
+	// backward branch closes a BEGIN at its target. A forward branch is
+	// the orig of an IF, WHILE or ELSE, and ends at the THEN, REPEAT or
+	// ELSE that resolved it.
+	type ctrl struct {
+		orig, while, do bool
+		at              int // where an orig goes, or the branch closing a BEGIN
+	}
+	var stack []ctrl
+	closes := map[int][]int{}
+	for _, in := range insns {
+		if name := in.word.Name; (name == "BRANCH" || name == "0BRANCH") && in.pos + 1 < len(ws) && ws[in.pos+1] < 0 {
+			target := in.pos + 1 + ws[in.pos+1]
+			closes[target] = append(closes[target], in.pos)
+		}
+	}
+	flow := func(in insn) (string) {
+		if in.pos + 1 >= len(ws) {
+			return ""
+		}
+		target := in.pos + 1 + ws[in.pos+1]
+		var top ctrl
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		switch _, ok := at[target]; {
+		case target <= in.pos:
+			if len(stack) == 0 || top.orig || top.do || top.at != in.pos {
+				return ""
+			}
+			stack = stack[:len(stack)-1]
+			if in.word.Name == "0BRANCH" {
+				return "UNTIL"
+			}
+			if n := len(stack); n > 0 && stack[n-1].while && stack[n-1].at == in.pos + 2 {
+				stack = stack[:n-1]
+				return "REPEAT"
+			}
+			return "AGAIN"
+		case !ok:
+			return ""
+		case in.word.Name == "BRANCH":
+			if len(stack) == 0 || !top.orig || top.at != in.pos + 2 {
+				return ""
+			}
+			stack[len(stack)-1] = ctrl{orig: true, at: target}
+			return "ELSE"
+		case len(stack) > 0 && !top.orig && !top.do && top.at < target:
+			// The loop closes before the branch lands, so it leaves the
+			// loop. WHILE puts its orig under the BEGIN.
+			stack = append(stack[:len(stack)-1], ctrl{orig: true, while: true, at: target}, top)
+			return "WHILE"
+		default:
+			stack = append(stack, ctrl{orig: true, at: target})
+			return "IF"
+		}
+	}
+
+	src := []string{":", word.Name}
+	for i, in := range insns {
+		for len(stack) > 0 && stack[len(stack)-1].orig && stack[len(stack)-1].at == in.pos {
+			src = append(src, "THEN")
+			stack = stack[:len(stack)-1]
+		}
+		// Loops that start together close innermost first.
+		for c, j := closes[in.pos], len(closes[in.pos])-1; j >= 0; j-- {
+			src = append(src, "BEGIN")
+			stack = append(stack, ctrl{at: c[j]})
+		}
+
+		switch in.word.Name {
+		case "BRANCH", "0BRANCH":
+			if s := flow(in); s != "" {
+				src = append(src, s)
+			} else {
+				src = append(src, "[", num(ws[in.pos]), "COMPILE,", num(ws[in.pos+1]), "COMPILE, ]")
+			}
+		case "(DO)", "(?DO)":
+			src = append(src, strings.Trim(in.word.Name, "()"))
+			stack = append(stack, ctrl{do: true})
+		case "(LOOP)", "(+LOOP)":
+			src = append(src, strings.Trim(in.word.Name, "()"))
+			if n := len(stack); n > 0 && stack[n-1].do {
+				stack = stack[:n-1]
+			}
+		case "'":
+			src = append(src, "'", f.source(in.pos+1, ws))
+		case "LIT":
+			src = append(src, num(ws[in.pos+1]))
+		case "LITSTRING":
+			s := f.str(ws[in.pos+1], ws[in.pos+2])
+			tell := i + 1 < len(insns) && insns[i+1].word.Name == "TELL"
+			switch {
+			case tell && plain(s):
+				src = append(src, `." ` + s + `"`)
+				insns[i+1].word = &ForthWord{Name: "(TOLD)"}
+			case plain(s):
+				src = append(src, `S" ` + s + `"`)
+			default:
+				src = append(src, `S\" ` + escape(s) + `"`)
+			}
+		case "(TOLD)":
+		case "EXIT":
+			if i == len(insns)-1 {
+				src = append(src, ";")
+			} else {
+				src = append(src, "EXIT")
+			}
+		case "(DOES>)":
+			src = append(src, "DOES>")
+		default:
+			if ws[in.pos] == code {
+				src = append(src, "RECURSE")
+			} else {
+				src = append(src, f.source(in.pos, ws))
+			}
+		}
+	}
+	if word.Immediate {
+		src = append(src, "IMMEDIATE")
+	}
+
+	return strings.Join(strings.Fields(strings.Join(src, " ")), " ")
+}
+
+// source returns source that compiles the code at ws[pos].
+func (f *AnnexiaForth) source(pos int, ws []int) (string) {
+	code := ws[pos]
+	_, w := f.Pages.FindCode(code)
+	if w != nil {
+		if c, found := f.Lookup(w.Name); found == w && c == code {
+			if w.Immediate {
+				return "[ ' " + w.Name + " COMPILE, ]"
+			}
+			return w.Name
+		}
+	}
+	return "[ " + strconv.FormatInt(int64(code), f.radix()) + " COMPILE, ]"
+}
+
+// plain reports whether s can be written between S" and " as is.
+func plain(s string) (bool) {
+	for i := 0; i < len(s); i++ {
+		if s[i] < ' ' || s[i] > '~' || s[i] == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+// escape writes s for S\".
+func escape(s string) (string) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			b.WriteString(`\q`)
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c < ' ' || c > '~':
+			b.WriteString(`\x` + strconv.FormatInt(int64(c) | 0x100, 16)[1:])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package annexia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Words returns the names of the words the search order can find, from
+// the top of the search order and newest first. A name shadowed by a
+// word found earlier is left out.
+func (f *AnnexiaForth) Words() (names []string) {
+	seen, lists := map[string]bool{}, map[int]bool{}
+	for i := len(f.Order)-1; i >= 0; i-- {
+		wid := f.Order[i]
+		if lists[wid] {
+			continue
+		}
+		lists[wid] = true
+
+		for p := f.Pages; p != nil; p = p.Parent {
+			for w := len(p.Dict)-1; w >= 0; w-- {
+				word := p.Dict[w]
+				if word.Hidden || word.List != wid || seen[word.Name] {
+					continue
+				}
+				seen[word.Name] = true
+				names = append(names, word.Name)
+			}
+		}
+	}
+	return
+}
+
+// unsigned returns the cell v as an unsigned number.
+func (f *AnnexiaForth) unsigned(v int) (uint64) {
+	u := uint64(v)
+	if f.CellSize < 8 {
+		u &= 1 << (8 * uint(f.CellSize)) - 1
+	}
+	return u
+}
+
+// Dump formats n bytes of data space from addr as hex and ASCII, 16 to a
+// line.
+func (f *AnnexiaForth) Dump(addr, n int) (s string, err error) {
+	err = try(func() { s = f.dump(addr, n) })
+	return
+}
+
+// dump is Dump for primitives.
+func (f *AnnexiaForth) dump(addr, n int) (string) {
+	var b strings.Builder
+	mem := f.bytes(addr, n)
+	for i := 0; i < len(mem); i += 16 {
+		line := mem[i:]
+		if len(line) > 16 {
+			line = line[:16]
+		}
+		fmt.Fprintf(&b, "%08x ", addr + i)
+		for j := 0; j < 16; j++ {
+			if j == 8 {
+				b.WriteByte(' ')
+			}
+			if j < len(line) {
+				fmt.Fprintf(&b, " %02x", line[j])
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		b.WriteString("  |")
+		for _, c := range line {
+			if c < ' ' || c > '~' {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteString("|\n")
+	}
+	return b.String()
+}
+
+// Environment answers an ENVIRONMENT? query with the cells it pushes, or
+// false if name is not known.
+func (f *AnnexiaForth) Environment(name string) ([]int, bool) {
+	max := int(f.unsigned(-1) >> 1)
+	switch strings.ToUpper(name) {
+	case "/COUNTED-STRING":
+		return []int{255}, true
+	case "ADDRESS-UNIT-BITS":
+		return []int{8}, true
+	case "FLOORED":
+		return []int{0}, true
+	case "MAX-CHAR":
+		return []int{255}, true
+	case "MAX-N":
+		return []int{max}, true
+	case "MAX-U":
+		return []int{-1}, true
+	case "RETURN-STACK-CELLS":
+		return []int{f.RStackSize}, true
+	case "STACK-CELLS":
+		return []int{f.DStackSize}, true
+	case "WORDLISTS":
+		return []int{SearchOrderSize}, true
+	case "CELL-BITS":
+		return []int{8 * f.CellSize}, true
+	}
+	return nil, false
+}
+
+// rjust right aligns s in a field of n characters.
+func rjust(s string, n int) (string) {
+	if len(s) >= n {
+		return s
+	}
+	return strings.Repeat(" ", n - len(s)) + s
+}
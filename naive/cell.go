@@ -0,0 +1,51 @@
+package naive
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type CellKind int
+
+const (
+	KindInt CellKind = iota
+	KindAddr
+	KindXT
+	KindStr
+	KindToken
+)
+
+// Cell is a typed value on the stack, in Memory or in the data space. Int
+// holds integers, addresses and execution tokens, Str holds strings and
+// tokens.
+type Cell struct {
+	Kind CellKind
+	Int  int64
+	Str  string
+}
+
+// Int is an integer cell.
+func Int(v int64) Cell { return Cell{Kind: KindInt, Int: v} }
+
+// Addr is the address of cell a of the data space.
+func Addr(a int64) Cell { return Cell{Kind: KindAddr, Int: a} }
+
+// XT is the execution token of the definition at v in Memory.
+func XT(v int64) Cell { return Cell{Kind: KindXT, Int: v} }
+
+// Str is a string cell, as pushed by SOURCE.
+func Str(s string) Cell { return Cell{Kind: KindStr, Str: s} }
+
+// Token is a word to execute, as read from the input or compiled into
+// Memory.
+func Token(name string) Cell { return Cell{Kind: KindToken, Str: name} }
+
+func (c Cell) String() string {
+	switch c.Kind {
+	case KindInt:  return strconv.FormatInt(c.Int, 10)
+	case KindAddr: return fmt.Sprintf("&%d", c.Int)
+	case KindXT:   return fmt.Sprintf("xt:%d", c.Int)
+	case KindStr:  return strconv.Quote(c.Str)
+	default:       return c.Str
+	}
+}
@@ -0,0 +1,90 @@
+package naive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrStackUnderflow  = errors.New("stack underflow")
+	ErrNotInteger      = errors.New("non integer value on stack")
+	ErrNotAddress      = errors.New("non address value on stack")
+	ErrNotXT           = errors.New("non execution token on stack")
+	ErrUnknownWord     = errors.New("unknown word")
+	ErrUnknownVariable = errors.New("unknown variable")
+	ErrDivisionByZero  = errors.New("division by zero")
+	ErrInvalidAddress  = errors.New("invalid memory address")
+	ErrZeroLengthName  = errors.New("attempt to use zero-length string as a name")
+	ErrNotCreated      = errors.New("DOES> used on non-CREATEd definition")
+	ErrNotValue        = errors.New("TO used on a word that is not a VALUE")
+	ErrCompileOnly     = errors.New("interpreting a compile-only word")
+	ErrControlMismatch = errors.New("control structure mismatch")
+	ErrInvalidState    = errors.New("invalid state")
+
+	ErrStepLimit          = errors.New("step limit exceeded")
+	ErrStackOverflow      = errors.New("stack overflow")
+	ErrReturnOverflow     = errors.New("return stack overflow")
+	ErrDataOverflow       = errors.New("data space overflow")
+	ErrDictionaryOverflow = errors.New("dictionary overflow")
+	ErrOutputLimit        = errors.New("output limit exceeded")
+	ErrInterrupt          = errors.New("user interrupt")
+)
+
+// Error is returned by Interpret and Eval. Err holds one of the Err
+// values above, possibly wrapped with the offending operand.
+type Error struct {
+	Err       error
+	Word      string
+	Line, Col int
+	Stack     []Cell
+	Backtrace []string
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("%d:%d: %s: %v %v", e.Line, e.Col, e.Word, e.Err, e.Stack)
+	if len(e.Backtrace) > 0 {
+		s += " in " + strings.Join(e.Backtrace, " < ")
+	}
+	return s
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrap adds the token at rsp and the dictionary words on RStack to err.
+// Memory is only walked when lis is the compiled code rather than input.
+func (f *Forth) wrap(err error, lis []Cell, rsp, start int64, RStack []int64) error {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Err: err, Stack: append([]Cell(nil), f.Stack...)}
+		if rsp < int64(len(lis)) {
+			if c := lis[rsp]; c.Kind == KindToken || c.Kind == KindStr {
+				e.Word = strings.ToUpper(c.Str)
+			} else {
+				e.Word = c.String()
+			}
+		}
+	}
+
+	if start != 0 {
+		e.Backtrace = append(e.Backtrace, f.wordAt(rsp))
+		for i := len(RStack) - 1; i >= 0; i-- {
+			e.Backtrace = append(e.Backtrace, f.wordAt(RStack[i]))
+		}
+	}
+
+	return e
+}
+
+// wordAt returns the name of the definition that contains pos in Memory.
+func (f *Forth) wordAt(pos int64) (name string) {
+	best := int64(-1)
+	for k, v := range f.Dict {
+		if v <= pos && v > best {
+			best, name = v, k
+		}
+	}
+	return
+}
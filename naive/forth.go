@@ -1,9 +1,16 @@
 package naive
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
 	"strconv"
+	"unicode"
 
 	"sour.is/x/log"
 )
@@ -26,7 +33,7 @@ import (
 		log.SetVerbose(log.Vinfo)
 
 		forth := naive.NewForth()
-		forth.Execute(strings.Fields(naive.BOOTSTRAP), 0)
+		forth.Interpret(strings.NewReader(naive.BOOTSTRAP), -1)
 
 	    l, err := readline.NewEx(&readline.Config{
 			Prompt:          "\033[31m»\033[0m ",
@@ -54,8 +61,7 @@ import (
 				break
 			}
 
-			lis := strings.Fields(line)
-	        err = forth.Execute(lis, 0)
+	        err = forth.Interpret(strings.NewReader(line), 0)
 	        if err != nil {
 	        	log.Error(err)
 	        }
@@ -119,24 +125,89 @@ var BOOTSTRAP string = `
 
 type ForthState int
 
+var compileOnly = map[string]bool{
+	";": true, "LITERAL": true,
+	"IF": true, "ELSE": true, "THEN": true,
+	"BEGIN": true, "UNTIL": true, "AGAIN": true, "WHILE": true, "REPEAT": true,
+	"DO": true, "LOOP": true, "+LOOP": true, "LEAVE": true, "DOES>": true,
+}
+
+// primitives are the words ' can take the execution token of without a
+// definition in Memory. Words that parse or need an inline operand are
+// left out.
+var primitives = map[string]bool{
+	"EXECUTE": true, "SOURCE": true, "SOURCE-ID": true, "REFILL": true,
+	"DEPTH": true, ".S": true, "WORDS": true,
+	"!": true, "+!": true, "@": true, "HERE": true, "ALLOT": true, ",": true,
+	"CELLS": true, "CELL+": true, "CREATE": true,
+	"VARIABLE": true, "2VARIABLE": true, "BUFFER:": true,
+	"CONSTANT": true, "2CONSTANT": true, "VALUE": true,
+	".": true, "I": true, "J": true, "UNLOOP": true,
+	"=": true, "<>": true, "<": true, ">": true, "0=": true, "0<": true, "0>": true,
+	"DROP": true, "SWAP": true, "DUP": true, "OVER": true, "ROT": true, "-ROT": true,
+	"2DROP": true, "2DUP": true, "2SWAP": true, "?DUP": true,
+	"1+": true, "1-": true, "4+": true, "4-": true,
+	"+": true, "-": true, "*": true, "/MOD": true,
+	"SPACES": true, "EMIT": true, "BYE": true,
+}
+
+// Addresses of BASE and >IN in the data space. Variables are allotted
+// after them.
+const (
+	AddrBase int64 = iota
+	AddrToIn
+)
+
+// checkInterval is how many instructions Execute runs between checks of
+// the context passed to InterpretContext.
+const checkInterval = 256
+
 const (
 	StateInterpret ForthState = iota
 	StateDefinition
 	StateCompile
-	StateComment
-	StateDotQuote
 	StateSee
 	StateExit
 )  
 
 type Forth struct {
 	State   ForthState
-	Stack   []string
-	QStack  []string
-	DStack  []string
+	Stack   []Cell
+	DStack  []Cell
 	Dict    map[string]int64
 	Vars    map[string]int64
-	Memory  []string
+	Memory  []Cell
+	Data    []Cell
+
+	Input    *bufio.Reader
+	SourceID int
+	TIB      string
+	ToIn     int
+	Line     int
+	Stdout   io.Writer
+
+	// Limits on what a script may use, checked by Execute as it runs.
+	// Zero means no limit. Steps and output are counted from the start of
+	// each Eval.
+	MaxSteps  int64
+	MaxStack  int
+	MaxReturn int
+	MaxData   int
+	MaxMemory int
+	MaxOutput int64
+
+	steps    int64
+	output   int64
+	depth    int
+	ctx      context.Context
+	name     string
+	col      int
+	ctrl     []int
+	fixups   []int
+	leaves   []int
+	values   map[string]int64
+	xts      map[string]int64
+	created  int64
 }
 
 func NewForth() (f *Forth) {
@@ -144,11 +215,17 @@ func NewForth() (f *Forth) {
 
 	f = new(Forth)
 	f.State = StateInterpret
+	f.Stdout = os.Stdout
 	
 	f.Dict = make(map[string]int64)
 	f.Vars = make(map[string]int64)
-	f.Vars["BASE"] = 10
-	f.Memory = append(f.Memory, "BYE")
+	f.values = make(map[string]int64)
+	f.xts = make(map[string]int64)
+	f.Data = []Cell{AddrBase: Int(10), AddrToIn: Int(0)}
+	f.Vars["BASE"] = AddrBase
+	f.Vars[">IN"] = AddrToIn
+	f.Memory = append(f.Memory, Token("BYE"))
+	f.created = -1
 
 	return
 }
@@ -158,141 +235,705 @@ func (fs ForthState) String() string {
 	case StateInterpret:  return "StateInterpret"
 	case StateDefinition: return "StateDefinition"
 	case StateCompile:    return "StateCompile"
-	case StateComment:    return "StateComment"
-	case StateDotQuote:   return "StateDotQuote"
 	case StateSee:        return "StateSee"
 	default:              return "UNKNOWN"
 	}
 }
 
-func (f *Forth) Execute(lis []string, start int64) (err error) {
-	name := ""
+// Eval interprets in. Any error is returned as an *Error, and a definition
+// in progress is discarded.
+func (f *Forth) Eval(in string) (err error) {
+	return f.EvalContext(context.Background(), in)
+}
+
+// EvalContext is Eval that stops with ErrInterrupt once ctx is done.
+func (f *Forth) EvalContext(ctx context.Context, in string) (err error) {
+	f.steps, f.output = 0, 0
+	if err = f.InterpretContext(ctx, strings.NewReader(in), -1); err != nil {
+		f.State, f.DStack, f.ctrl, f.fixups, f.leaves = StateInterpret, nil, nil, nil, nil
+		if errors.Is(err, ErrStackOverflow) {
+			f.Stack = nil
+		}
+	}
+	return
+}
+
+// Interpret runs the outer interpreter over r until it is exhausted.
+// Parsing words read their argument from the input here and hand it to
+// Execute as the following token.
+func (f *Forth) Interpret(r io.Reader, id int) (err error) {
+	return f.InterpretContext(context.Background(), r, id)
+}
+
+// InterpretContext is Interpret that stops with ErrInterrupt once ctx is
+// done. The error also wraps the error of ctx.
+func (f *Forth) InterpretContext(ctx context.Context, r io.Reader, id int) (err error) {
+	input, sourceID, tib, toIn, line, prev := f.Input, f.SourceID, f.TIB, f.ToIn, f.Line, f.ctx
+	defer func() {
+		f.Input, f.SourceID, f.TIB, f.ToIn, f.Line, f.ctx = input, sourceID, tib, toIn, line, prev
+	}()
+
+	f.Input, f.SourceID, f.TIB, f.ToIn, f.Line = bufio.NewReader(r), id, "", 0, 0
+	f.ctx = ctx
+
+	for f.Refill() {
+		for token := f.Word(); token != ""; token = f.Word() {
+			lis := []Cell{Token(token)}
+
+			switch strings.ToUpper(token) {
+			case "(":
+				f.Parse(')')
+				continue
+			case `\`:
+				f.ToIn = len(f.TIB)
+				continue
+			case `."`:
+				lis = append(lis, Str(f.Parse('"')))
+			case ":", "SEE", "CHAR", "'":
+				lis = append(lis, Str(f.Word()))
+			}
+
+			if err = f.Execute(lis, 0); err != nil {
+				var e *Error
+				if errors.As(err, &e) && e.Line == 0 {
+					e.Line, e.Col = f.Line, f.col + 1
+				}
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Refill reads the next line of the input source into the TIB.
+func (f *Forth) Refill() bool {
+	if f.Input == nil {
+		return false
+	}
+
+	line, err := f.Input.ReadString('\n')
+	if err != nil && line == "" {
+		return false
+	}
+	f.TIB, f.ToIn = strings.TrimRight(line, "\r\n"), 0
+	f.Line++
+
+	return true
+}
+
+// Word skips leading whitespace and parses the next token from the TIB.
+func (f *Forth) Word() string {
+	for f.ToIn < len(f.TIB) && unicode.IsSpace(rune(f.TIB[f.ToIn])) {
+		f.ToIn++
+	}
+	f.col = f.ToIn
+	return f.Parse(' ')
+}
+
+// Parse returns the characters up to delim and moves >IN past it. A space
+// delimiter matches any whitespace.
+func (f *Forth) Parse(delim byte) string {
+	if f.ToIn > len(f.TIB) {
+		f.ToIn = len(f.TIB)
+	}
+
+	start := f.ToIn
+	for f.ToIn < len(f.TIB) {
+		c := f.TIB[f.ToIn]
+		if c == delim || delim == ' ' && unicode.IsSpace(rune(c)) {
+			break
+		}
+		f.ToIn++
+	}
+	token := f.TIB[start:f.ToIn]
+	if f.ToIn < len(f.TIB) {
+		f.ToIn++
+	}
+
+	return token
+}
+
+func (f *Forth) Execute(lis []Cell, start int64) (err error) {
 	var RStack []int64
+	var LStack []int64
+	var rsp int64
+
+	f.depth++
+	defer func() {
+		f.depth--
+		if err != nil {
+			err = f.wrap(err, lis, rsp, start, RStack)
+		}
+	}()
 
 START:
-	for rsp := start; rsp < int64(len(lis));  {
-		token := lis[rsp]
+	for rsp = start; rsp < int64(len(lis));  {
+		f.steps++
+		if err = f.limit(len(RStack)); err != nil {
+			return err
+		}
+		if f.steps % checkInterval == 0 && f.ctx != nil && f.ctx.Err() != nil {
+			return fmt.Errorf("%w: %w", ErrInterrupt, f.ctx.Err())
+		}
+		cell := lis[rsp]
+		token := cell.Str
 		TOKEN := strings.ToUpper(token)
 
 		switch(f.State) {
 		case StateDefinition:
-			name = strings.ToUpper(token)
-			log.Debugf("Begin Definition for %s", name)
+			f.name = strings.ToUpper(token)
+			log.Debugf("Begin Definition for %s", f.name)
 			f.State = StateCompile
 
 		case StateCompile:
 			switch(TOKEN) {
 			case "LITERAL":
-				var c string
-				c, f.DStack = f.DStack[len(f.DStack)-1], f.DStack[:len(f.DStack)-1] 
-				f.DStack = append(f.DStack, "LIT", c)
+				if len(f.DStack) == 0 {
+					return ErrStackUnderflow
+				}
+				var c Cell
+				c, f.DStack = f.DStack[len(f.DStack)-1], f.DStack[:len(f.DStack)-1]
+				f.DStack = append(f.DStack, Token("LIT"), c)
 
 			case "CHAR":
+				name, err := operand(lis, rsp)
+				if err != nil {
+					return err
+				}
+				rsp++
+				f.DStack = append(f.DStack, Int(int64(name[0])))
+			case "'":
+				name, err := operand(lis, rsp)
+				if err != nil {
+					return err
+				}
+				rsp++
+				v, err := f.xt(name)
+				if err != nil {
+					return err
+				}
+				f.DStack = append(f.DStack, Token("LIT"), XT(v))
+
+			case `."`:
 				rsp++
-				f.DStack = append(f.DStack, lis[rsp])
+				f.DStack = append(f.DStack, cell, lis[rsp])
 
 			case ":":
-				return fmt.Errorf(": INSIDE :")
+				return ErrInvalidState
+
+			case "TO", "+TO":
+				a, err := f.value(f.Word())
+				if err != nil {
+					return err
+				}
+				store := "!"
+				if TOKEN == "+TO" {
+					store = "+!"
+				}
+				f.DStack = append(f.DStack, Token("LIT"), Addr(a), Token(store))
+			case "DOES>":
+				f.DStack = append(f.DStack, Token("(DOES>)"))
+
+			case "IF":
+				f.branch("0BRANCH", 0)
+				f.ctrl = append(f.ctrl, len(f.DStack)-1)
+			case "ELSE":
+				orig, ok := f.cpop()
+				if !ok {
+					return ErrControlMismatch
+				}
+				f.branch("BRANCH", 0)
+				f.ctrl = append(f.ctrl, len(f.DStack)-1)
+				if !f.resolve(orig) {
+					return ErrControlMismatch
+				}
+			case "THEN":
+				orig, ok := f.cpop()
+				if !ok || !f.resolve(orig) {
+					return ErrControlMismatch
+				}
+			case "BEGIN":
+				f.ctrl = append(f.ctrl, len(f.DStack))
+			case "UNTIL", "AGAIN":
+				dest, ok := f.cpop()
+				if !ok {
+					return ErrControlMismatch
+				}
+				if TOKEN == "UNTIL" {
+					f.branch("0BRANCH", dest)
+				} else {
+					f.branch("BRANCH", dest)
+				}
+			case "WHILE":
+				if len(f.ctrl) < 1 {
+					return ErrControlMismatch
+				}
+				f.branch("0BRANCH", 0)
+				f.ctrl = append(f.ctrl, f.ctrl[len(f.ctrl)-1])
+				f.ctrl[len(f.ctrl)-2] = len(f.DStack)-1
+			case "REPEAT":
+				dest, ok := f.cpop()
+				orig, ok2 := f.cpop()
+				if !ok || !ok2 {
+					return ErrControlMismatch
+				}
+				f.branch("BRANCH", dest)
+				if !f.resolve(orig) {
+					return ErrControlMismatch
+				}
+			case "DO":
+				f.DStack = append(f.DStack, Token("(DO)"))
+				f.ctrl = append(f.ctrl, len(f.DStack))
+				f.leaves = append(f.leaves, -1)
+			case "LEAVE":
+				if len(f.leaves) == 0 {
+					return ErrControlMismatch
+				}
+				f.DStack = append(f.DStack, Token("UNLOOP"))
+				f.branch("BRANCH", 0)
+				f.leaves = append(f.leaves, len(f.DStack)-1)
+			case "LOOP", "+LOOP":
+				dest, ok := f.cpop()
+				if !ok || len(f.leaves) == 0 {
+					return ErrControlMismatch
+				}
+				f.branch("(" + TOKEN + ")", dest)
+				// Each LEAVE since the DO branches past the loop.
+				for f.leaves[len(f.leaves)-1] >= 0 {
+					f.resolve(f.leaves[len(f.leaves)-1])
+					f.leaves = f.leaves[:len(f.leaves)-1]
+				}
+				f.leaves = f.leaves[:len(f.leaves)-1]
 
 			case ";":
-				log.Debugf("Complete Definition for %s", name)
+				log.Debugf("Complete Definition for %s", f.name)
+				if len(f.ctrl) > 0 || len(f.leaves) > 0 {
+					return ErrControlMismatch
+				}
 				i := int64(len(f.Memory))
+				for _, at := range f.fixups {
+					f.DStack[at].Int += i
+				}
+				f.fixups = nil
+				if f.MaxMemory > 0 && len(f.Memory) + len(f.DStack) + 1 > f.MaxMemory {
+					return ErrDictionaryOverflow
+				}
 				f.Memory = append(f.Memory, f.DStack...)
-				f.Memory = append(f.Memory, "NEXT")
-				f.Dict[name] = i
+				f.Memory = append(f.Memory, Token("NEXT"))
+				delete(f.Vars, f.name)
+				delete(f.values, f.name)
+				delete(f.xts, f.name)
+				f.Dict[f.name] = i
 				f.DStack = nil
 				f.State = StateInterpret
 			case "[":
 			case "]":
 
 			default:
-				f.DStack = append(f.DStack, token)
-			}
-
-		case StateComment:
-			if token == ")" {
-				f.State = StateInterpret
-			}
-
-		case StateDotQuote:
-			if strings.HasSuffix(token, `"`) {
-				f.QStack = append(f.QStack, token[:len(token)-1])
-				log.Infof("QUOTE: %s", strings.Join(f.QStack, " "))
-				f.QStack = nil
-				f.State = StateInterpret
-			} else {
-				f.QStack = append(f.QStack, token)
+				// Numbers are converted once here rather than each time
+				// the definition runs.
+				if v, ok := to_int(token, f.base()); ok {
+					f.DStack = append(f.DStack, Int(v))
+				} else {
+					f.DStack = append(f.DStack, cell)
+				}
 			}
 
 		case StateSee:
 			f.State = StateInterpret
 			if v, ok := f.Dict[TOKEN]; ok {
-				var see []string
+				var see []Cell
 				for _, t := range f.Memory[v:] {
-					if t == "NEXT" {
+					if t == Token("NEXT") {
 						break
 					}
 					see = append(see, t)
 				}
-				fmt.Println(see)
+				if err = f.write(fmt.Sprintln(see)); err != nil {
+					return err
+				}
 			} else {
-				return fmt.Errorf("UNKN TOKEN: %s", token)
+				return ErrUnknownWord
 			}
 
 		case StateInterpret:
+			if cell.Kind != KindToken {
+				// Literals compiled into a definition push themselves.
+				f.Stack = append(f.Stack, cell)
+				break
+			}
+
 			switch(TOKEN){
 			case ":":
+				if _, err = operand(lis, rsp); err != nil {
+					return err
+				}
 				f.State = StateDefinition
-			case "(":
-				f.State = StateComment
 			case `."`:
-				f.State = StateDotQuote
+				rsp++
+				if err = f.write(lis[rsp].Str); err != nil {
+					return err
+				}
+			case "CHAR":
+				name, err := operand(lis, rsp)
+				if err != nil {
+					return err
+				}
+				rsp++
+				f.Stack = append(f.Stack, Int(int64(name[0])))
+			case "'":
+				name, err := operand(lis, rsp)
+				if err != nil {
+					return err
+				}
+				rsp++
+				v, err := f.xt(name)
+				if err != nil {
+					return err
+				}
+				f.Stack = append(f.Stack, XT(v))
+			case "EXECUTE":
+				if len(f.Stack) < 1 {
+					return ErrStackUnderflow
+				}
+				xt := f.Stack[len(f.Stack)-1]
+				if xt.Kind != KindXT {
+					return fmt.Errorf("%w: %s", ErrNotXT, xt)
+				}
+				f.Stack = f.Stack[:len(f.Stack)-1]
+				if start == 0 {
+					if err = f.Execute(f.Memory, xt.Int); err != nil {
+						return err
+					}
+				} else {
+					RStack = append(RStack, rsp)
+					rsp = xt.Int
+					continue START
+				}
+			case "SOURCE":
+				f.Stack = append(f.Stack, Str(f.TIB), Int(int64(len(f.TIB))))
+			case "SOURCE-ID":
+				f.Stack = append(f.Stack, Int(int64(f.SourceID)))
+			case "REFILL":
+				f.Stack = append(f.Stack, to_flag(f.SourceID != -1 && f.Refill()))
 			case "LIT":
+				c, err := inline(lis, rsp)
+				if err != nil {
+					return err
+				}
 				rsp++
-				f.Stack = append(f.Stack, lis[rsp])
+				f.Stack = append(f.Stack, c)
 			case "SEE":
 				f.State = StateSee
 			case "DEPTH":
-				f.Stack = append(f.Stack, fmt.Sprintf("i%d", len(f.Stack)))
+				f.Stack = append(f.Stack, Int(int64(len(f.Stack))))
+			case ".S":
+				s := fmt.Sprintf("<%d>", len(f.Stack))
+				for _, c := range f.Stack {
+					if c.Kind == KindInt && f.base() != 10 {
+						s += fmt.Sprintf(" 0x%x", c.Int)
+					} else {
+						s += " " + c.String()
+					}
+				}
+				if err = f.write(s + "\n"); err != nil {
+					return err
+				}
+			case "WORDS":
+				var names []string
+				for _, m := range []map[string]int64{f.Dict, f.Vars} {
+					for name := range m {
+						names = append(names, name)
+					}
+				}
+				sort.Strings(names)
+				if err = f.write(strings.Join(names, " ") + "\n"); err != nil {
+					return err
+				}
 			case "!":
+				dst, err := f.addr()
+				if err != nil {
+					return err
+				}
 				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
-				dst := f.Stack[len(f.Stack)-1]
-				v, ok := to_int(f.Stack[len(f.Stack)-2], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
+				if err = f.store(dst, f.Stack[len(f.Stack)-2]); err != nil {
+					return err
+				}
+				f.Stack = f.Stack[:len(f.Stack)-2]
+			case "+!":
+				dst, err := f.addr()
+				if err != nil {
+					return err
+				}
+				n, err := f.peek(1)
+				if err != nil {
+					return err
+				}
+				v, err := f.fetch(dst)
+				if err != nil {
+					return err
+				}
+				if v.Kind != KindInt {
+					return fmt.Errorf("%w: %s", ErrNotInteger, v)
+				}
+				if err = f.store(dst, Int(v.Int + n)); err != nil {
+					return err
 				}
-				f.Vars[dst] = v
 				f.Stack = f.Stack[:len(f.Stack)-2]
-				
 			case "@":
+				src, err := f.addr()
+				if err != nil {
+					return err
+				}
+				v, err := f.fetch(src)
+				if err != nil {
+					return err
+				}
+				f.Stack[len(f.Stack)-1] = v
+
+			case "HERE":
+				f.Stack = append(f.Stack, Addr(int64(len(f.Data))))
+			case "ALLOT":
+				n, err := f.peek(0)
+				if err != nil {
+					return err
+				}
+				if err = f.allot(n); err != nil {
+					return err
+				}
+				f.Stack = f.Stack[:len(f.Stack)-1]
+			case ",":
 				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
-				src := f.Stack[len(f.Stack)-1]
-				v, ok := f.Vars[src]
-				if !ok {
-					return fmt.Errorf("Variable not in memory: %s", f.Stack[len(f.Stack)-1])
+				f.Data = append(f.Data, f.Stack[len(f.Stack)-1])
+				f.Stack = f.Stack[:len(f.Stack)-1]
+			case "CELLS":
+				// Addresses count cells.
+				if _, err := f.peek(0); err != nil {
+					return err
+				}
+			case "CELL+":
+				if len(f.Stack) < 1 {
+					return ErrStackUnderflow
+				}
+				if c := &f.Stack[len(f.Stack)-1]; c.Kind == KindAddr || c.Kind == KindInt {
+					c.Int++
+				} else {
+					return fmt.Errorf("%w: %s", ErrNotAddress, *c)
 				}
-				log.Info(v, ok)
 
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", v)
+			case "VARIABLE", "2VARIABLE", "BUFFER:":
+				n := int64(1)
+				if TOKEN == "2VARIABLE" {
+					n = 2
+				} else if TOKEN == "BUFFER:" {
+					if n, err = f.peek(0); err != nil {
+						return err
+					}
+					f.Stack = f.Stack[:len(f.Stack)-1]
+				}
+				name, err := f.define()
+				if err != nil {
+					return err
+				}
+				f.Vars[name] = int64(len(f.Data))
+				if err = f.allot(n); err != nil {
+					return err
+				}
+			case "CONSTANT", "2CONSTANT":
+				n := 1
+				if TOKEN == "2CONSTANT" {
+					n = 2
+				}
+				if len(f.Stack) < n {
+					return ErrStackUnderflow
+				}
+				name, err := f.define()
+				if err != nil {
+					return err
+				}
+				f.Dict[name] = int64(len(f.Memory))
+				for _, c := range f.Stack[len(f.Stack)-n:] {
+					f.Memory = append(f.Memory, Token("LIT"), c)
+				}
+				f.Memory = append(f.Memory, Token("NEXT"))
+				f.Stack = f.Stack[:len(f.Stack)-n]
+			case "VALUE":
+				if len(f.Stack) < 1 {
+					return ErrStackUnderflow
+				}
+				name, err := f.define()
+				if err != nil {
+					return err
+				}
+				f.values[name] = int64(len(f.Data))
+				f.Dict[name] = int64(len(f.Memory))
+				f.Memory = append(f.Memory, Token("LIT"), Addr(int64(len(f.Data))), Token("@"), Token("NEXT"))
+				f.Data = append(f.Data, f.Stack[len(f.Stack)-1])
+				f.Stack = f.Stack[:len(f.Stack)-1]
+			case "TO", "+TO":
+				a, err := f.value(f.Word())
+				if err != nil {
+					return err
+				}
+				if len(f.Stack) < 1 {
+					return ErrStackUnderflow
+				}
+				v := f.Stack[len(f.Stack)-1]
+				if TOKEN == "+TO" {
+					if v.Kind != KindInt || f.Data[a].Kind != KindInt {
+						return fmt.Errorf("%w: %s", ErrNotInteger, v)
+					}
+					v = Int(f.Data[a].Int + v.Int)
+				}
+				f.Data[a] = v
+				f.Stack = f.Stack[:len(f.Stack)-1]
+			case "CREATE":
+				name, err := f.define()
+				if err != nil {
+					return err
+				}
+				// The second NEXT leaves room for DOES> to branch away.
+				f.created = int64(len(f.Memory))
+				f.Dict[name] = f.created
+				f.Memory = append(f.Memory, Token("LIT"), Addr(int64(len(f.Data))), Token("NEXT"), Token("NEXT"))
+			case "(DOES>)":
+				if start == 0 {
+					return ErrCompileOnly
+				}
+				if f.created < 0 {
+					return ErrNotCreated
+				}
+				f.Memory[f.created+2], f.Memory[f.created+3] = Token("BRANCH"), Int(rsp+1)
+				if len(RStack) == 0 {
+					return nil
+				}
+				RStack, rsp = RStack[:len(RStack)-1], RStack[len(RStack)-1]
 			case ".":
 				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
-				var v string
+				var v Cell
 				f.Stack, v = f.Stack[:len(f.Stack)-1], f.Stack[len(f.Stack)-1]
-				if i, ok := to_int(v, 10); ok {
-					if f.Vars["BASE"] == 10 {
-						fmt.Printf("%d\n", i)
+				s := fmt.Sprintln("POP:", v)
+				if v.Kind == KindInt {
+					if f.base() == 10 {
+						s = fmt.Sprintf("%d\n", v.Int)
 					} else {
-						fmt.Printf("0x%x\n", i)
+						s = fmt.Sprintf("0x%x\n", v.Int)
 					}
+				}
+				if err = f.write(s); err != nil {
+					return err
+				}
+			case "BRANCH":
+				c, err := inline(lis, rsp)
+				if err != nil {
+					return err
+				}
+				rsp = c.Int
+				continue START
+			case "0BRANCH":
+				c, err := inline(lis, rsp)
+				if err != nil {
+					return err
+				}
+				v, err := f.peek(0)
+				if err != nil {
+					return err
+				}
+				f.Stack = f.Stack[:len(f.Stack)-1]
+				rsp++
+				if v == 0 {
+					rsp = c.Int
+					continue START
+				}
+			case "(DO)":
+				index, err := f.peek(0)
+				if err != nil {
+					return err
+				}
+				limit, err := f.peek(1)
+				if err != nil {
+					return err
+				}
+				f.Stack = f.Stack[:len(f.Stack)-2]
+				LStack = append(LStack, limit, index)
+			case "(LOOP)", "(+LOOP)":
+				if len(LStack) < 2 {
+					return ErrControlMismatch
+				}
+				c, err := inline(lis, rsp)
+				if err != nil {
+					return err
+				}
+				n := int64(1)
+				if TOKEN == "(+LOOP)" {
+					if n, err = f.peek(0); err != nil {
+						return err
+					}
+					f.Stack = f.Stack[:len(f.Stack)-1]
+				}
+				rsp++
+				d := LStack[len(LStack)-1] - LStack[len(LStack)-2]
+				if n >= 0 && d < 0 && d + n >= 0 || n < 0 && d >= 0 && d + n < 0 {
+					LStack = LStack[:len(LStack)-2]
 				} else {
-					fmt.Println("POP:", v)
+					LStack[len(LStack)-1] += n
+					rsp = c.Int
+					continue START
+				}
+			case "UNLOOP":
+				if len(LStack) < 2 {
+					return ErrControlMismatch
+				}
+				LStack = LStack[:len(LStack)-2]
+			case "I":
+				if len(LStack) < 2 {
+					return ErrControlMismatch
+				}
+				f.Stack = append(f.Stack, Int(LStack[len(LStack)-1]))
+			case "J":
+				if len(LStack) < 4 {
+					return ErrControlMismatch
+				}
+				f.Stack = append(f.Stack, Int(LStack[len(LStack)-3]))
+
+			case "=", "<>", "<", ">":
+				n, err := f.peek(0)
+				if err != nil {
+					return err
+				}
+				i, err := f.peek(1)
+				if err != nil {
+					return err
 				}
+				var b bool
+				switch TOKEN {
+				case "=":  b = i == n
+				case "<>": b = i != n
+				case "<":  b = i < n
+				case ">":  b = i > n
+				}
+				f.Stack = f.Stack[:len(f.Stack)-1]
+				f.Stack[len(f.Stack)-1] = to_flag(b)
+			case "0=", "0<", "0>":
+				i, err := f.peek(0)
+				if err != nil {
+					return err
+				}
+				var b bool
+				switch TOKEN {
+				case "0=": b = i == 0
+				case "0<": b = i < 0
+				case "0>": b = i > 0
+				}
+				f.Stack[len(f.Stack)-1] = to_flag(b)
+
 			case "NEXT":
 				if len(RStack) == 0 {
 					return nil
@@ -304,182 +945,133 @@ START:
 				return nil
 			case "DROP":
 				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				f.Stack = f.Stack[:len(f.Stack)-1]
 			case "SWAP":
 				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-1] = f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2]
 			case "DUP":
 				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				f.Stack = append(f.Stack, f.Stack[len(f.Stack)-1])
 			case "OVER":
 				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				f.Stack = append(f.Stack, f.Stack[len(f.Stack)-2])
 			case "ROT":
 				if len(f.Stack) < 3 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				a, b, c := f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3]
-				f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3] = b, c, a
+				f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3] = c, a, b
 			case "-ROT":
 				if len(f.Stack) < 3 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				a, b, c := f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3]
-				f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3] = a, c, b
+				f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3] = b, c, a
 			case "2DROP":
 				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				f.Stack = f.Stack[:len(f.Stack)-2]
 			case "2DUP":
 				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				f.Stack = append(f.Stack, f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-1])
 			case "2SWAP":
 				if len(f.Stack) < 4 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
 				a, b, c, d := f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3], f.Stack[len(f.Stack)-4]
-				f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3], f.Stack[len(f.Stack)-4] = c, d, a, b 
+				f.Stack[len(f.Stack)-1], f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-3], f.Stack[len(f.Stack)-4] = c, d, a, b
 			case "?DUP":
 				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
-				}
-				if f.Stack[len(f.Stack)-1] != "i0" {
-					f.Stack = append(f.Stack, f.Stack[len(f.Stack)-1])
-				}
-			case "1+":
-				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+					return ErrStackUnderflow
 				}
-				i, ok := to_int(f.Stack[len(f.Stack)-1],10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
+				if c := f.Stack[len(f.Stack)-1]; c.Kind != KindInt || c.Int != 0 {
+					f.Stack = append(f.Stack, c)
 				}
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", i + 1)
-			case "1-":
-				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+			case "1+", "1-", "4+", "4-":
+				i, err := f.peek(0)
+				if err != nil {
+					return err
 				}
-				i, ok := to_int(f.Stack[len(f.Stack)-1],10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
-				}
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", i - 1)
-			case "4+":
-				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+				switch TOKEN {
+				case "1+": i += 1
+				case "1-": i -= 1
+				case "4+": i += 4
+				case "4-": i -= 4
 				}
-				i, ok := to_int(f.Stack[len(f.Stack)-1],10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
+				f.Stack[len(f.Stack)-1] = Int(i)
+			case "+", "-", "*":
+				if f.offset(TOKEN) {
+					break
 				}
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", i + 4)
-			case "4-":
-				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+				n, err := f.peek(0)
+				if err != nil {
+					return err
 				}
-				i, ok := to_int(f.Stack[len(f.Stack)-1],10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
+				i, err := f.peek(1)
+				if err != nil {
+					return err
 				}
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", i - 4)
-			case "+":
-				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
-				}
-				n, ok := to_int(f.Stack[len(f.Stack)-1], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
-				}
-				i, ok := to_int(f.Stack[len(f.Stack)-2], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-2])
-				}
-				f.Stack = f.Stack[:len(f.Stack)-1]
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", n + i)
-			case "-":
-				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
-				}
-				n, ok := to_int(f.Stack[len(f.Stack)-1], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
-				}
-				i, ok := to_int(f.Stack[len(f.Stack)-2], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-2])
+				switch TOKEN {
+				case "+": i += n
+				case "-": i -= n
+				case "*": i *= n
 				}
 				f.Stack = f.Stack[:len(f.Stack)-1]
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", i - n)
-			case "*":
-				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
-				}
-				var ok bool
-				var n, i int64
-				if n, ok = to_int(f.Stack[len(f.Stack)-1], 10); !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
-				}
-				if i, ok = to_int(f.Stack[len(f.Stack)-2], 10); !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-2])
-				}
-				f.Stack = f.Stack[:len(f.Stack)-1]
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", i * n)
+				f.Stack[len(f.Stack)-1] = Int(i)
 			case "/MOD":
-				if len(f.Stack) < 2 {
-					return fmt.Errorf("Insufficent Stack Size")
-				}
-				d, ok := to_int(f.Stack[len(f.Stack)-1], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
+				d, err := f.peek(0)
+				if err != nil {
+					return err
 				}
-				n, ok := to_int(f.Stack[len(f.Stack)-2], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-2])
+				n, err := f.peek(1)
+				if err != nil {
+					return err
 				}
 				if d == 0 {
-					return fmt.Errorf("Div by Zero")
+					return ErrDivisionByZero
 				}
-				f.Stack[len(f.Stack)-2] = fmt.Sprintf("i%d", n % d)
-				f.Stack[len(f.Stack)-1] = fmt.Sprintf("i%d", n / d)
+				f.Stack[len(f.Stack)-2] = Int(n % d)
+				f.Stack[len(f.Stack)-1] = Int(n / d)
 			case "SPACES":
-				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
+				n, err := f.peek(0)
+				if err != nil {
+					return err
 				}
-				n, ok := to_int(f.Stack[len(f.Stack)-1], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
+				f.Stack = f.Stack[:len(f.Stack)-1]
+				if n > 0 {
+					if err = f.write(strings.Repeat(" ", int(n))); err != nil {
+						return err
+					}
 				}
-				fmt.Print(strings.Repeat(" ", int(n)))
 			case "EMIT":
-				if len(f.Stack) < 1 {
-					return fmt.Errorf("Insufficent Stack Size")
-				}
-				c, ok := to_int(f.Stack[len(f.Stack)-1], 10)
-				if !ok {
-					return fmt.Errorf("Non integer value on stack: %s", f.Stack[len(f.Stack)-1])
+				c, err := f.peek(0)
+				if err != nil {
+					return err
 				}
-					fmt.Printf("%c", c)
 				f.Stack = f.Stack[:len(f.Stack)-1]
+				if err = f.write(string(rune(c))); err != nil {
+					return err
+				}
 
 			default:
 				// log.Debug("Fallthrough to dict/vars")
-				if v, ok := to_int(token, f.Vars["BASE"]); ok {
+				if v, ok := to_int(token, f.base()); ok {
 					// log.Debugf("PUSH: %d", v)
-					f.Stack = append(f.Stack, fmt.Sprintf("i%d", v))
-				
-				} else if _, ok := f.Vars[TOKEN]; ok {
-					f.Stack = append(f.Stack, TOKEN)
+					f.Stack = append(f.Stack, Int(v))
+
+				} else if a, ok := f.Vars[TOKEN]; ok {
+					f.Stack = append(f.Stack, Addr(a))
 
 				} else if v, ok := f.Dict[TOKEN]; ok {
 					log.Debugf("Executing: %s @ %d", TOKEN, v)
@@ -495,13 +1087,15 @@ START:
 					if err != nil {
 						return err
 					}
+				} else if compileOnly[TOKEN] {
+					return ErrCompileOnly
 				} else {
-					return fmt.Errorf("UNKN TOKEN: %s", TOKEN)
+					return ErrUnknownWord
 				}
 			}
 
 		default:
-			return fmt.Errorf("UNKN STATE: %s", f.State)
+			return fmt.Errorf("%w: %s", ErrInvalidState, f.State)
 		}
 
 		rsp++
@@ -510,12 +1104,243 @@ START:
 	return
 }
 
-func to_int(token string, base int64) (int64, bool) {
-	if token[0] == 'i' {
-		token = token[1:]
-		base = 10
+// branch compiles op followed by its target. Targets are relative to the
+// start of the definition until ";" rebases them into Memory.
+func (f *Forth) branch(op string, target int) {
+	f.DStack = append(f.DStack, Token(op), Int(int64(target)))
+	f.fixups = append(f.fixups, len(f.DStack)-1)
+}
+
+// resolve points the forward branch target at orig to the next token. It
+// reports false if orig is not an unresolved forward branch but, say, the
+// destination a BEGIN or DO left.
+func (f *Forth) resolve(orig int) bool {
+	if orig < 1 || orig >= len(f.DStack) || f.DStack[orig] != Int(0) {
+		return false
+	}
+	if op := f.DStack[orig-1]; op != Token("BRANCH") && op != Token("0BRANCH") {
+		return false
+	}
+	f.DStack[orig] = Int(int64(len(f.DStack)))
+	return true
+}
+
+func (f *Forth) cpop() (int, bool) {
+	if len(f.ctrl) == 0 {
+		return 0, false
+	}
+	v := f.ctrl[len(f.ctrl)-1]
+	f.ctrl = f.ctrl[:len(f.ctrl)-1]
+	return v, true
+}
+
+// inline returns the cell compiled after the word at rsp. Words that take
+// one are compile-only, so there is none when they are interpreted.
+func inline(lis []Cell, rsp int64) (Cell, error) {
+	if rsp + 1 >= int64(len(lis)) {
+		return Cell{}, ErrCompileOnly
+	}
+	return lis[rsp+1], nil
+}
+
+// operand returns the name Interpret parsed for the word at rsp.
+func operand(lis []Cell, rsp int64) (string, error) {
+	if rsp + 1 >= int64(len(lis)) || lis[rsp+1].Str == "" {
+		return "", ErrZeroLengthName
+	}
+	return lis[rsp+1].Str, nil
+}
+
+// peek returns the integer n cells below the top of the stack.
+func (f *Forth) peek(n int) (int64, error) {
+	if len(f.Stack) <= n {
+		return 0, ErrStackUnderflow
+	}
+	c := f.Stack[len(f.Stack)-1-n]
+	if c.Kind != KindInt {
+		return 0, fmt.Errorf("%w: %s", ErrNotInteger, c)
+	}
+	return c.Int, nil
+}
+
+// addr returns the address on top of the stack.
+func (f *Forth) addr() (int64, error) {
+	if len(f.Stack) < 1 {
+		return 0, ErrStackUnderflow
+	}
+	c := f.Stack[len(f.Stack)-1]
+	if c.Kind != KindAddr {
+		return 0, fmt.Errorf("%w: %s", ErrNotAddress, c)
+	}
+	return c.Int, nil
+}
+
+// offset does + or - on the top two cells if one of them is an address,
+// and reports whether it did.
+func (f *Forth) offset(op string) bool {
+	if len(f.Stack) < 2 || op == "*" {
+		return false
+	}
+	a, b := f.Stack[len(f.Stack)-2], f.Stack[len(f.Stack)-1]
+	var c Cell
+	switch {
+	case a.Kind == KindAddr && b.Kind == KindInt && op == "+":
+		c = Addr(a.Int + b.Int)
+	case a.Kind == KindInt && b.Kind == KindAddr && op == "+":
+		c = Addr(a.Int + b.Int)
+	case a.Kind == KindAddr && b.Kind == KindInt && op == "-":
+		c = Addr(a.Int - b.Int)
+	case a.Kind == KindAddr && b.Kind == KindAddr && op == "-":
+		c = Int(a.Int - b.Int)
+	default:
+		return false
+	}
+	f.Stack = f.Stack[:len(f.Stack)-1]
+	f.Stack[len(f.Stack)-1] = c
+	return true
+}
+
+// fetch returns the cell at addr. >IN is backed by f.ToIn.
+func (f *Forth) fetch(addr int64) (Cell, error) {
+	if addr == AddrToIn {
+		return Int(int64(f.ToIn)), nil
 	}
+	if addr < 0 || addr >= int64(len(f.Data)) {
+		return Cell{}, fmt.Errorf("%w: %d", ErrInvalidAddress, addr)
+	}
+	return f.Data[addr], nil
+}
+
+// store writes c to the cell at addr.
+func (f *Forth) store(addr int64, c Cell) error {
+	if addr < 0 || addr >= int64(len(f.Data)) {
+		return fmt.Errorf("%w: %d", ErrInvalidAddress, addr)
+	}
+	if addr == AddrToIn {
+		if c.Kind != KindInt {
+			return fmt.Errorf("%w: %s", ErrNotInteger, c)
+		}
+		f.ToIn = int(c.Int)
+	}
+	f.Data[addr] = c
+	return nil
+}
+
+// allot adds n zeroed cells to the data space, or removes them if n is
+// negative.
+func (f *Forth) allot(n int64) error {
+	if n < 0 {
+		if int64(len(f.Data)) + n <= AddrToIn {
+			return ErrInvalidAddress
+		}
+		f.Data = f.Data[:int64(len(f.Data)) + n]
+	}
+	if f.MaxData > 0 && int64(len(f.Data)) + n > int64(f.MaxData) {
+		return ErrDataOverflow
+	}
+	for ; n > 0; n-- {
+		f.Data = append(f.Data, Int(0))
+	}
+	return nil
+}
+
+// limit checks the limits the last instruction may have crossed. rdepth
+// is the depth of the return stack of the current Execute.
+func (f *Forth) limit(rdepth int) error {
+	switch {
+	case f.MaxSteps > 0 && f.steps > f.MaxSteps:
+		return ErrStepLimit
+	case f.MaxStack > 0 && len(f.Stack) > f.MaxStack:
+		return ErrStackOverflow
+	case f.MaxReturn > 0 && f.depth + rdepth > f.MaxReturn:
+		return ErrReturnOverflow
+	case f.MaxData > 0 && len(f.Data) > f.MaxData:
+		return ErrDataOverflow
+	case f.MaxMemory > 0 && len(f.Memory) > f.MaxMemory:
+		return ErrDictionaryOverflow
+	}
+	return nil
+}
 
+// write prints s to Stdout, or as much of it as MaxOutput allows.
+func (f *Forth) write(s string) error {
+	if f.MaxOutput > 0 && f.output + int64(len(s)) > f.MaxOutput {
+		io.WriteString(f.Stdout, s[:f.MaxOutput - f.output])
+		f.output = f.MaxOutput
+		return ErrOutputLimit
+	}
+	f.output += int64(len(s))
+	_, err := io.WriteString(f.Stdout, s)
+	return err
+}
+
+// base returns the value of BASE.
+func (f *Forth) base() int64 {
+	return f.Data[AddrBase].Int
+}
+
+// define parses the name of a new data word and drops any earlier word of
+// that name, since variables are found before colon definitions.
+func (f *Forth) define() (string, error) {
+	name := strings.ToUpper(f.Word())
+	if name == "" {
+		return "", ErrZeroLengthName
+	}
+	delete(f.Vars, name)
+	delete(f.values, name)
+	delete(f.Dict, name)
+	delete(f.xts, name)
+	return name, nil
+}
+
+// xt returns the execution token of name. Variables and primitives have
+// no code in Memory, so the first ' of one compiles a definition that
+// runs it.
+func (f *Forth) xt(name string) (int64, error) {
+	name = strings.ToUpper(name)
+	if _, ok := f.Vars[name]; !ok {
+		if v, ok := f.Dict[name]; ok {
+			return v, nil
+		}
+	}
+	if v, ok := f.xts[name]; ok {
+		return v, nil
+	}
+
+	var code []Cell
+	if a, ok := f.Vars[name]; ok {
+		code = []Cell{Token("LIT"), Addr(a)}
+	} else if primitives[name] {
+		code = []Cell{Token(name)}
+	} else {
+		return 0, ErrUnknownWord
+	}
+	if f.MaxMemory > 0 && len(f.Memory) + len(code) + 1 > f.MaxMemory {
+		return 0, ErrDictionaryOverflow
+	}
+	v := int64(len(f.Memory))
+	f.Memory = append(append(f.Memory, code...), Token("NEXT"))
+	f.xts[name] = v
+	return v, nil
+}
+
+// value returns the address of the VALUE called name.
+func (f *Forth) value(name string) (int64, error) {
+	a, ok := f.values[strings.ToUpper(name)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrNotValue, name)
+	}
+	return a, nil
+}
+
+func to_flag(b bool) Cell {
+	if b {
+		return Int(-1)
+	}
+	return Int(0)
+}
+
+func to_int(token string, base int64) (int64, bool) {
 	if i, err := strconv.ParseInt(token, int(base), 64); err == nil {
 		return i, true
 	}
@@ -0,0 +1,215 @@
+package naive
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testForth returns a bootstrapped Forth writing to the returned buffer.
+func testForth(t testing.TB) (*Forth, *bytes.Buffer) {
+	t.Helper()
+	var out bytes.Buffer
+	f := NewForth()
+	f.Stdout = &out
+	if err := f.Eval(BOOTSTRAP); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	return f, &out
+}
+
+// eval runs each of srcs and returns what they printed.
+func eval(t testing.TB, f *Forth, out *bytes.Buffer, srcs ...string) string {
+	t.Helper()
+	out.Reset()
+	for _, src := range srcs {
+		if err := f.Eval(src); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+	}
+	return out.String()
+}
+
+func TestInputSource(t *testing.T) {
+	f, out := testForth(t)
+	if got := eval(t, f, out, "SOURCE . DROP >IN @ . SOURCE-ID ."); got != "33\n20\n-1\n" {
+		t.Errorf("got %q", got)
+	}
+	if got := eval(t, f, out, `CHAR ( . ( 1 . ) 2 . \ 3 .`); got != "40\n2\n" {
+		t.Errorf("got %q", got)
+	}
+
+	out.Reset()
+	if err := f.Interpret(strings.NewReader("REFILL 1 .\n2 .\n"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "2\n" || len(f.Stack) != 1 || f.Stack[0] != Int(-1) {
+		t.Errorf("got %q, stack %v", out.String(), f.Stack)
+	}
+	f.Stack = nil
+
+	for _, src := range []string{":", "CHAR", "'", "VARIABLE"} {
+		if err := f.Eval(src); !errors.Is(err, ErrZeroLengthName) {
+			t.Errorf("%s: got %v, want %v", src, err, ErrZeroLengthName)
+		}
+	}
+}
+
+func TestControlFlow(t *testing.T) {
+	f, out := testForth(t)
+	for _, tt := range []struct{ src, want string }{
+		{": SIGN DUP 0< IF DROP -1 ELSE 0> IF 1 ELSE 0 THEN THEN . ; -5 SIGN 0 SIGN 7 SIGN", "-1\n0\n1\n"},
+		{": SUM 0 SWAP 0 DO I + LOOP . ; 5 SUM", "10\n"},
+		{": EVENS 10 0 DO I . 2 +LOOP ; EVENS", "0\n2\n4\n6\n8\n"},
+		{": GRID 2 0 DO 2 0 DO J I + . LOOP LOOP ; GRID", "0\n1\n1\n2\n"},
+		{": FIRST 10 0 DO I 3 = IF LEAVE THEN I . LOOP 99 . ; FIRST", "0\n1\n2\n99\n"},
+		{": INNER 3 0 DO 3 0 DO I 1 = IF LEAVE THEN J . LOOP LOOP ; INNER", "0\n1\n2\n"},
+		{": DOWN BEGIN DUP . 1- DUP 0= UNTIL DROP ; 3 DOWN", "3\n2\n1\n"},
+		{": COUNTUP 0 BEGIN DUP 3 < WHILE DUP . 1+ REPEAT DROP ; COUNTUP", "0\n1\n2\n"},
+	} {
+		if got := eval(t, f, out, tt.src); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	f.MaxSteps = 1000
+	if err := f.Eval(": SPIN BEGIN 1+ AGAIN ; 0 SPIN"); !errors.Is(err, ErrStepLimit) {
+		t.Errorf("AGAIN: got %v, want %v", err, ErrStepLimit)
+	}
+	f.MaxSteps, f.Stack = 0, nil
+
+	for _, src := range []string{": X 1 IF ;", ": X BEGIN ;", ": X 10 0 DO ;", ": X THEN ;", ": X LEAVE ;", ": X REPEAT ;", ": X 10 0 DO THEN ;", ": X BEGIN ELSE ;", ": X BEGIN 0 WHILE THEN AGAIN ;"} {
+		if err := f.Eval(src); !errors.Is(err, ErrControlMismatch) {
+			t.Errorf("%s: got %v, want %v", src, err, ErrControlMismatch)
+		}
+		if _, ok := f.Dict["X"]; ok {
+			t.Errorf("%s: X was defined", src)
+		}
+	}
+	if err := f.Eval("IF"); !errors.Is(err, ErrCompileOnly) {
+		t.Errorf("IF: got %v, want %v", err, ErrCompileOnly)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, tt := range []struct {
+		src  string
+		want error
+	}{
+		{"DROP", ErrStackUnderflow},
+		{"1 0 /MOD", ErrDivisionByZero},
+		{"FOO", ErrUnknownWord},
+		{"THEN", ErrCompileOnly},
+		{"1 @", ErrNotAddress},
+		{"1 EXECUTE", ErrNotXT},
+		{"HERE 100 + @", ErrInvalidAddress},
+		{"5 TO FOO", ErrNotValue},
+		{"VARIABLE V V V *", ErrNotInteger},
+		{": X : ;", ErrInvalidState},
+	} {
+		f, _ := testForth(t)
+		err := f.Eval(tt.src)
+		var e *Error
+		if !errors.Is(err, tt.want) || !errors.As(err, &e) {
+			t.Errorf("%s: got %v, want %v", tt.src, err, tt.want)
+		}
+	}
+
+	f, _ := testForth(t)
+	err := f.Eval("1 2\n: A 0 0 /MOD ; : B A ;\n  B")
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want an *Error", err)
+	}
+	if e.Word != "/MOD" || e.Line != 3 || e.Col != 3 || len(e.Stack) != 4 ||
+		strings.Join(e.Backtrace, " ") != "A B" {
+		t.Errorf("got %#v", e)
+	}
+}
+
+func TestTick(t *testing.T) {
+	f, out := testForth(t)
+	got := eval(t, f, out,
+		"5 ' DUP EXECUTE + .",
+		": SQ ' DUP EXECUTE * ; 4 SQ .",
+		"VARIABLE V 7 V ! ' V EXECUTE @ . ' BASE EXECUTE @ .",
+		"3 ' DOUBLE EXECUTE .",
+	)
+	if got != "10\n16\n7\n10\n6\n" {
+		t.Errorf("got %q", got)
+	}
+	n := len(f.Memory)
+	if eval(t, f, out, "' V DROP ' DUP DROP"); len(f.Memory) != n {
+		t.Errorf("' compiled %d more cells for words it had seen", len(f.Memory) - n)
+	}
+	for _, src := range []string{"' FOO", "' LIT", ": X ' IF ;"} {
+		if err := f.Eval(src); !errors.Is(err, ErrUnknownWord) {
+			t.Errorf("%s: got %v, want %v", src, err, ErrUnknownWord)
+		}
+	}
+}
+
+func TestRedefine(t *testing.T) {
+	f, out := testForth(t)
+	got := eval(t, f, out,
+		"VARIABLE V ' V DROP : V 9 ; V . ' V EXECUTE .",
+		"3 VALUE W : W 8 ; W .",
+		": C 1 ; 2 CONSTANT C C .",
+		"5 CONSTANT K VARIABLE K 6 K ! K @ .",
+	)
+	if got != "9\n9\n8\n2\n6\n" {
+		t.Errorf("got %q", got)
+	}
+	if err := f.Eval("4 TO W"); !errors.Is(err, ErrNotValue) {
+		t.Errorf("TO W: got %v, want %v", err, ErrNotValue)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		set  func(*Forth)
+		src  string
+		want error
+	}{
+		{"MaxSteps", func(f *Forth) { f.MaxSteps = 1000 }, ": L BEGIN AGAIN ; L", ErrStepLimit},
+		{"MaxStack", func(f *Forth) { f.MaxStack = 8 }, ": P 0 BEGIN DUP 1+ AGAIN ; P", ErrStackOverflow},
+		{"MaxReturn", func(f *Forth) { f.MaxReturn = 8 }, ": R R ; R", ErrReturnOverflow},
+		{"MaxData", func(f *Forth) { f.MaxData = len(f.Data) + 2 }, "VARIABLE A VARIABLE B VARIABLE C", ErrDataOverflow},
+		{"MaxData", func(f *Forth) { f.MaxData = len(f.Data) + 2 }, "3 ALLOT", ErrDataOverflow},
+		{"MaxMemory", func(f *Forth) { f.MaxMemory = len(f.Memory) + 4 }, ": M 1 2 3 4 5 ;", ErrDictionaryOverflow},
+		{"MaxMemory", func(f *Forth) { f.MaxMemory = len(f.Memory) }, "' DUP", ErrDictionaryOverflow},
+		{"MaxOutput", func(f *Forth) { f.MaxOutput = 5 }, `." hello world"`, ErrOutputLimit},
+	} {
+		f, out := testForth(t)
+		tt.set(f)
+		if err := f.Eval(tt.src); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %s: got %v, want %v", tt.name, tt.src, err, tt.want)
+		}
+		if tt.want == ErrOutputLimit && out.String() != "hello" {
+			t.Errorf("%s: printed %q", tt.name, out.String())
+		}
+		if tt.want == ErrStackOverflow && len(f.Stack) != 0 {
+			t.Errorf("%s: left %d cells on the stack", tt.name, len(f.Stack))
+		}
+		// Each Eval starts counting steps and output again.
+		out.Reset()
+		if err := f.Eval("1 ."); err != nil || out.String() != "1\n" {
+			t.Errorf("%s: Eval after the limit: %q, %v", tt.name, out.String(), err)
+		}
+	}
+}
+
+func TestEvalResetsCompiler(t *testing.T) {
+	f, out := testForth(t)
+	for _, src := range []string{": X 1 IF ;", ": Y 1 ELSE ;", "1 0 /MOD"} {
+		if err := f.Eval(src); err == nil {
+			t.Errorf("%s: no error", src)
+		}
+		if got := eval(t, f, out, "1 2 + ."); got != "3\n" || f.State != StateInterpret {
+			t.Errorf("after %s: got %q in %s", src, got, f.State)
+		}
+	}
+}